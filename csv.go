@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nilium/resify/rtype"
+)
+
+// The -export formats for a table of one of a resume's list sections (see csvSections), with comma- or tab-separated
+// values.
+const (
+	exportCSV = "csv"
+	exportTSV = "tsv"
+)
+
+// csvSections are the sections that -export-section may name, by their keys in resume files, and the header and rows of
+// each when exported as CSV.
+var csvSections = map[string]func(r rtype.Resume) (header []string, rows [][]string){
+	"work": func(r rtype.Resume) ([]string, [][]string) {
+		rows := make([][]string, len(r.Employment))
+		for i, e := range r.Employment {
+			rows[i] = []string{e.Title, e.Where.Name, e.Where.Line(), csvDate(e.When.From), csvEndDate(e.When), e.Description,
+				strings.Join(e.Highlights, "\n")}
+		}
+		return []string{"title", "employer", "location", "from", "to", "description", "highlights"}, rows
+	},
+	"education": func(r rtype.Resume) ([]string, [][]string) {
+		rows := make([][]string, len(r.Education))
+		for i, e := range r.Education {
+			rows[i] = []string{e.Where.Name, e.Where.Line(), e.Received, strings.Join(e.Fields, "; "),
+				csvDate(e.When.From), csvEndDate(e.When), e.Description, strings.Join(e.Highlights, "\n")}
+		}
+		return []string{"school", "location", "received", "fields", "from", "to", "description", "highlights"}, rows
+	},
+	"awards": func(r rtype.Resume) ([]string, [][]string) {
+		rows := make([][]string, len(r.Awards))
+		for i, a := range r.Awards {
+			rows[i] = []string{a.Title, a.Awarder, csvDate(a.Date.From), a.Summary}
+		}
+		return []string{"title", "awarder", "date", "summary"}, rows
+	},
+	"publications": func(r rtype.Resume) ([]string, [][]string) {
+		rows := make([][]string, len(r.Publications))
+		for i, p := range r.Publications {
+			rows[i] = []string{p.Title, p.Publisher, csvDate(p.Date.From), p.URL, p.Summary}
+		}
+		return []string{"title", "publisher", "date", "url", "summary"}, rows
+	},
+	"references": func(r rtype.Resume) ([]string, [][]string) {
+		rows := make([][]string, len(r.References))
+		for i, ref := range r.References {
+			rows[i] = []string{ref.Name, ref.Relationship, ref.Contact, ref.Note}
+		}
+		return []string{"name", "relationship", "contact", "note"}, rows
+	},
+}
+
+// csvSectionNames returns the keys of csvSections in the order they appear in resume files.
+func csvSectionNames() []string {
+	var names []string
+	for _, s := range rtype.Sections {
+		if _, ok := csvSections[s]; ok {
+			names = append(names, s)
+		}
+	}
+	return names
+}
+
+// csvDate formats t with the month layout, 2006-01. Zero times are empty.
+func csvDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01")
+}
+
+// csvEndDate formats the end of d as csvDate does, or as "present" if d is ongoing.
+func csvEndDate(d rtype.DateRange) string {
+	if d.To.IsZero() && !d.From.IsZero() {
+		return "present"
+	}
+	return csvDate(d.To)
+}
+
+// marshalCSV returns the entries of the section of resume named by its key, such as "work", as CSV with a header row. If
+// tabs is true, fields are separated by tabs instead of commas.
+func marshalCSV(resume rtype.Resume, section string, tabs bool) ([]byte, error) {
+	table, ok := csvSections[section]
+	if !ok {
+		return nil, fmt.Errorf("cannot export section %q as CSV; must be one of %s", section, strings.Join(csvSectionNames(), ", "))
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if tabs {
+		w.Comma = '\t'
+	}
+	header, rows := table(resume)
+	w.Write(header)
+	w.WriteAll(rows)
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nilium/resify/rtype"
+)
+
+func TestMarshalCSV(t *testing.T) {
+	month := func(y int, m time.Month) time.Time { return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC) }
+	resume := rtype.Resume{
+		Employment: []rtype.Employment{
+			{
+				Title:       "Engineer",
+				Where:       rtype.Place{Name: "Acme, Inc."},
+				When:        rtype.DateRange{From: month(2019, 3)},
+				Description: `Built "things"`,
+			},
+			{
+				Title:      "Intern",
+				Where:      rtype.Place{Name: "Initech"},
+				When:       rtype.DateRange{From: month(2018, 6), To: month(2018, 9)},
+				Highlights: []string{"Filed things", "Fixed a bug"},
+			},
+		},
+	}
+
+	table := []struct {
+		section string
+		tabs    bool
+		want    string
+	}{
+		{"work", false, "title,employer,location,from,to,description,highlights\n" +
+			"Engineer,\"Acme, Inc.\",,2019-03,present,\"Built \"\"things\"\"\",\n" +
+			"Intern,Initech,,2018-06,2018-09,,\"Filed things\nFixed a bug\"\n"},
+		{"work", true, "title\temployer\tlocation\tfrom\tto\tdescription\thighlights\n" +
+			"Engineer\tAcme, Inc.\t\t2019-03\tpresent\t\"Built \"\"things\"\"\"\t\n" +
+			"Intern\tInitech\t\t2018-06\t2018-09\t\t\"Filed things\nFixed a bug\"\n"},
+		{"awards", false, "title,awarder,date,summary\n"},
+	}
+
+	for _, e := range table {
+		got, err := marshalCSV(resume, e.section, e.tabs)
+		if err != nil {
+			t.Errorf("unexpected error exporting %s (tabs=%t): %v", e.section, e.tabs, err)
+		} else if string(got) != e.want {
+			t.Errorf("marshalCSV(%s, tabs=%t) = %q; want %q", e.section, e.tabs, got, e.want)
+		}
+	}
+
+	if got, err := marshalCSV(resume, "skills", false); err == nil {
+		t.Errorf("expected error exporting an unknown section; got %q", got)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+
+	"github.com/nilium/resify/rtype"
+)
+
+// envVarFormat matches a ${VAR} reference to an environment variable. Bare $VAR references aren't expanded, so that text
+// such as "$5 million" is left alone.
+var envVarFormat = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnv replaces ${VAR} references in the string values of the decoded resume r with the values of the environment
+// variables they name. Only strings are rewritten, so numbers, dates, and mapping keys keep the text they were decoded
+// from. Unset variables expand to an empty string, unless required is true, in which case they're an error.
+func expandEnv(r *rtype.Resume, required bool) error {
+	var unset []string
+	expand := func(s string) string {
+		return os.Expand(s, func(name string) string {
+			v, ok := os.LookupEnv(name)
+			if !ok {
+				unset = append(unset, name)
+			}
+			return v
+		})
+	}
+
+	expandEnvValue(reflect.ValueOf(r).Elem(), func(s string) string {
+		return envVarFormat.ReplaceAllStringFunc(s, expand)
+	})
+	if required && len(unset) > 0 {
+		return fmt.Errorf("environment variable %s is not set", unset[0])
+	}
+
+	// Profile URLs are normalized when decoded, so normalize them again in case a reference expanded to a bare host.
+	for k, p := range r.Profiles.Profile {
+		p.URL = rtype.NormalizeURL(p.URL)
+		r.Profiles.Profile[k] = p
+	}
+	return nil
+}
+
+// expandEnvValue applies expand to each string held by v, which must be settable, including those in its exported struct
+// fields, slices, map values, and interface values. Map keys and unexported fields are left alone.
+func expandEnvValue(v reflect.Value, expand func(string) string) {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(expand(v.String()))
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Field(i); f.CanSet() {
+				expandEnvValue(f, expand)
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			expandEnvValue(v.Index(i), expand)
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			e := reflect.New(v.Type().Elem()).Elem()
+			e.Set(v.MapIndex(k))
+			expandEnvValue(e, expand)
+			v.SetMapIndex(k, e)
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		e := reflect.New(v.Elem().Type()).Elem()
+		e.Set(v.Elem())
+		expandEnvValue(e, expand)
+		v.Set(e)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nilium/resify/rtype"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("RESIFY_EMAIL", "me@example.com")
+	t.Setenv("RESIFY_HOST", "example.com")
+
+	src := "me:\n  email: ${RESIFY_EMAIL}\n  notes: [\"${RESIFY_HOST}\", 7]\n${RESIFY_EMAIL}: key\n" +
+		"profiles:\n  site: {url: \"${RESIFY_HOST}/me\"}\n" +
+		"work:\n- title: Paid $5 for ${RESIFY_UNSET}\n" +
+		"  desc: See ((https://${RESIFY_HOST}/work the work)).\n  when: {from: 2014-05-01}\n  hours: 40\n" +
+		"  where: {name: 1.10, postal: 01234}\n"
+
+	var r rtype.Resume
+	if err := yaml.Unmarshal([]byte(src), &r); err != nil {
+		t.Fatalf("unexpected error decoding: %v", err)
+	}
+	if err := expandEnv(&r, false); err != nil {
+		t.Fatalf("unexpected error expanding: %v", err)
+	}
+
+	if r.Me.Email != "me@example.com" {
+		t.Errorf("Me.Email = %q; expected %q", r.Me.Email, "me@example.com")
+	}
+	if notes := r.Me.Meta["notes"]; !reflect.DeepEqual(notes, []interface{}{"example.com", 7}) {
+		t.Errorf("Me.Meta[notes] = %#v; expected the string expanded and the number left alone", notes)
+	}
+	if v := r.Meta["${RESIFY_EMAIL}"]; v != "key" {
+		t.Errorf("expected the key ${RESIFY_EMAIL} to be left alone; got meta %v", r.Meta)
+	}
+	if url := r.Profiles.Profile["site"].URL; url != "https://example.com/me" {
+		t.Errorf("profile URL = %q; expected %q", url, "https://example.com/me")
+	}
+
+	w := r.Employment[0]
+	if w.Title != "Paid $5 for " {
+		t.Errorf("Title = %q; expected %q", w.Title, "Paid $5 for ")
+	}
+	if want := "See ((https://example.com/work the work))."; w.Description != want {
+		t.Errorf("Description = %q; expected %q", w.Description, want)
+	}
+	if w.When.From.Year() != 2014 || w.Meta["hours"] != 40 {
+		t.Errorf("expected the date and hours to be left alone; got %v and %v", w.When, w.Meta["hours"])
+	}
+	if w.Where.Name != "1.10" || w.Where.Postal != "01234" {
+		t.Errorf("expected numbers in string fields to keep their text; got name %q, postal %q", w.Where.Name, w.Where.Postal)
+	}
+
+	r = rtype.Resume{Summary: "${RESIFY_UNSET}"}
+	if err := expandEnv(&r, true); err == nil {
+		t.Error("expected an error expanding an unset variable when required")
+	}
+}
+
+func TestReadResumeExpandEnv(t *testing.T) {
+	t.Setenv("RESIFY_EMAIL", "me@example.com")
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"resume.yaml": "me: {chosen: Me, email: \"${RESIFY_EMAIL}\"}\nawards:\n- title: Award\n  date: 2014-05\n" +
+			"work:\n- title: ${RESIFY_EMAIL}\n  where: {postal: 01234}\n",
+	})
+
+	r, err := readResumeFromFile(filepath.Join(dir, "resume.yaml"), readOptions{ExpandEnv: true})
+	if err != nil {
+		t.Fatalf("unexpected error reading resume: %v", err)
+	}
+	if r.Me.Email != "me@example.com" {
+		t.Errorf("expected expanded email; got %q", r.Me.Email)
+	}
+	if len(r.Awards) != 1 || r.Awards[0].Date.From.Year() != 2014 {
+		t.Errorf("expected award date to survive expansion; got %+v", r.Awards)
+	}
+	if len(r.Employment) != 1 || r.Employment[0].Where.Postal != "01234" {
+		t.Errorf("expected zero-padded postal code to survive expansion; got %+v", r.Employment)
+	}
+}
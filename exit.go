@@ -0,0 +1,44 @@
+package main
+
+import "errors"
+
+// Exit codes returned by resify, so that scripts can tell what kind of problem stopped it.
+const (
+	exitOK       = 0 // Everything succeeded.
+	exitFailure  = 1 // Anything not covered by another code, such as being interrupted.
+	exitUsage    = 2 // The command, flags, or arguments given are invalid. This is also what the flag package exits with.
+	exitParse    = 3 // A resume file could not be read or parsed, or validate found problems in one.
+	exitTemplate = 4 // Templates could not be loaded, parsed, or executed.
+	exitIO       = 5 // Output or other files could not be written, or the serve command could not listen.
+	exitStale    = 6 // render -check found output that isn't up to date.
+)
+
+// exitError is an error along with the exit code resify returns for it.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// withExitCode returns err with the exit code given, or nil if err is nil.
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{code: code, err: err}
+}
+
+// exitCode returns the exit code for err: exitOK if err is nil, the code given by withExitCode, or exitFailure otherwise.
+func exitCode(err error) int {
+	var e *exitError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &e):
+		return e.code
+	default:
+		return exitFailure
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	err := errors.New("failed")
+
+	table := []struct {
+		err  error
+		want int
+	}{
+		{nil, exitOK},
+		{err, exitFailure},
+		{withExitCode(exitTemplate, err), exitTemplate},
+		{fmt.Errorf("rendering: %w", withExitCode(exitIO, err)), exitIO},
+		{withExitCode(exitParse, nil), exitOK},
+	}
+
+	for _, e := range table {
+		if got := exitCode(e.err); got != e.want {
+			t.Errorf("exitCode(%v) = %d; want %d", e.err, got, e.want)
+		}
+	}
+
+	if wrapped := withExitCode(exitParse, err); !errors.Is(wrapped, err) || wrapped.Error() != "failed" {
+		t.Errorf("expected %v to wrap %v", wrapped, err)
+	}
+}
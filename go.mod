@@ -1,6 +1,7 @@
 module "github.com/nilium/resify"
 
 require (
+	"github.com/BurntSushi/toml" v0.3.1
 	"github.com/shurcooL/sanitized_anchor_name" v0.0.0-20170918181015-86672fcb3f95
 	"gopkg.in/russross/blackfriday.v2" v1.0.0-gopkgin-v2.0.0
 	"gopkg.in/yaml.v2" v1.1.1-gopkgin-v2.1.1
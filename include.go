@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"github.com/nilium/resify/render"
+	"github.com/nilium/resify/rtype"
+)
+
+// includeResumes reads the resumes listed by r.Include and merges them into r, processing their own includes first. Include
+// paths are relative to the directory of path, which is the file r was read from, and must stay inside that directory once
+// symlinks are resolved. The stack holds the resolved paths of the files currently being included, and is used to detect
+// include cycles. Seen holds the resolved paths of every file included so far, so that a file included more than once,
+// such as by two files that are both included, is only merged the first time. Errors are logged before being returned.
+func includeResumes(r *rtype.Resume, path string, opts readOptions, stack []string, seen map[string]bool) error {
+	includes := r.Include
+	r.Include = nil
+
+	name, dir := path, filepath.Dir(path)
+	if path == "-" || path == "" {
+		name, dir = "stdin", "."
+	}
+
+	for _, inc := range includes {
+		incPath := filepath.Join(dir, filepath.Clean(inc))
+		abs, err := resolveDataPath(dir, inc)
+		if errors.Is(err, render.ErrEscapeAttempt) {
+			err = errIncludeEscape
+		} else if opts.Included != nil {
+			opts.Included(incPath)
+		}
+		if err != nil {
+			log.Printf("cannot include %s from %s: %v", inc, name, err)
+			return err
+		}
+
+		for _, p := range stack {
+			if p == abs {
+				err = fmt.Errorf("include cycle through %s", incPath)
+				log.Printf("cannot include %s from %s: %v", inc, name, err)
+				return err
+			}
+		}
+
+		if seen[abs] {
+			continue
+		}
+		seen[abs] = true
+
+		sub, err := decodeResumeFile(incPath, opts)
+		if err != nil {
+			return err
+		}
+
+		if err = includeResumes(&sub, incPath, opts, append(stack[:len(stack):len(stack)], abs), seen); err != nil {
+			return err
+		}
+
+		r.Merge(sub)
+	}
+
+	return nil
+}
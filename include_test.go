@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIncludeResumes(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"resume.yaml":        "me: {chosen: Me}\ninclude: [work.yaml, sub/education.yaml]\nwork:\n- title: First\n",
+		"work.yaml":          "me: {chosen: Other, email: me@example.com}\nwork:\n- title: Second\n",
+		"sub/education.yaml": "include: [more.toml]\neducation:\n- where: {name: School}\n",
+		"sub/more.toml":      "[[work]]\ntitle = \"Third\"\n",
+		"cycle.yaml":         "include: [cycle2.yaml]\n",
+		"cycle2.yaml":        "include: [cycle.yaml]\n",
+		"self.yaml":          "include: [./self.yaml]\n",
+		"escape.yaml":        "include: [sub/../../outside.yaml]\n",
+	})
+
+	r, err := readResumeFromFile(filepath.Join(dir, "resume.yaml"), readOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error reading resume: %v", err)
+	}
+
+	if r.Me.Chosen != "Me" || r.Me.Email != "me@example.com" {
+		t.Errorf("unexpected Me after merging: %+v", r.Me)
+	}
+
+	var titles []string
+	for _, e := range r.Employment {
+		titles = append(titles, e.Title)
+	}
+	if len(titles) != 3 || titles[0] != "First" || titles[1] != "Second" || titles[2] != "Third" {
+		t.Errorf("unexpected work after merging: %q", titles)
+	}
+
+	if len(r.Education) != 1 || r.Education[0].Where.Name != "School" {
+		t.Errorf("unexpected education after merging: %+v", r.Education)
+	}
+
+	if len(r.Include) != 0 {
+		t.Errorf("expected includes to be cleared; got %q", r.Include)
+	}
+
+	for _, name := range []string{"cycle.yaml", "self.yaml", "escape.yaml"} {
+		if _, err := readResumeFromFile(filepath.Join(dir, name), readOptions{}); err == nil {
+			t.Errorf("expected an error reading %s", name)
+		}
+	}
+}
+
+func TestIncludeSymlinkEscape(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"outside.yaml":        "work:\n- title: Outside\n",
+		"resume/resume.yaml":  "include: [link.yaml]\n",
+		"resume/inside.yaml":  "work:\n- title: Inside\n",
+		"resume/linked.yaml":  "include: [inside-link.yaml]\n",
+		"resume/sub/sub.yaml": "include: [../inside.yaml]\n",
+	})
+	dir := filepath.Join(root, "resume")
+	for link, target := range map[string]string{
+		"link.yaml":        filepath.Join(root, "outside.yaml"),
+		"inside-link.yaml": "inside.yaml",
+	} {
+		if err := os.Symlink(target, filepath.Join(dir, link)); err != nil {
+			t.Skipf("cannot create symlink: %v", err)
+		}
+	}
+
+	if _, err := readResumeFromFile(filepath.Join(dir, "resume.yaml"), readOptions{}); !errors.Is(err, errIncludeEscape) {
+		t.Errorf("expected errIncludeEscape including a symlink out of the resume directory; got %v", err)
+	}
+
+	r, err := readResumeFromFile(filepath.Join(dir, "linked.yaml"), readOptions{})
+	if err != nil || len(r.Employment) != 1 || r.Employment[0].Title != "Inside" {
+		t.Errorf("expected a symlink inside the resume directory to be included; got %+v, %v", r.Employment, err)
+	}
+
+	// Includes are relative to the including file and can't leave its directory, even into the top-level resume's.
+	if _, err := readResumeFromFile(filepath.Join(dir, "sub", "sub.yaml"), readOptions{}); !errors.Is(err, errIncludeEscape) {
+		t.Errorf("expected errIncludeEscape including a parent directory's file; got %v", err)
+	}
+}
+
+func TestIncludeDiamond(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.yaml": "include: [b.yaml, c.yaml]\nwork:\n- title: A\n",
+		"b.yaml": "include: [d.yaml]\nwork:\n- title: B\n",
+		"c.yaml": "include: [./d.yaml, d-link.yaml]\nwork:\n- title: C\n",
+		"d.yaml": "work:\n- title: D\n",
+	})
+	if err := os.Symlink("d.yaml", filepath.Join(dir, "d-link.yaml")); err != nil {
+		t.Skipf("cannot create symlink: %v", err)
+	}
+
+	r, err := readResumeFromFile(filepath.Join(dir, "a.yaml"), readOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error reading resume: %v", err)
+	}
+
+	// D is merged once, into B, which includes it first; C's includes of it are skipped.
+	var titles []string
+	for _, e := range r.Employment {
+		titles = append(titles, e.Title)
+	}
+	if want := []string{"A", "B", "D", "C"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("expected work %q; got %q", want, titles)
+	}
+}
+
+func TestIncludedCallback(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.yaml":     "include: [b.yaml, sub/c.yaml]\n",
+		"b.yaml":     "include: [sub/c.yaml]\n",
+		"sub/c.yaml": "include: [missing.yaml]\n",
+	})
+
+	var got []string
+	opts := readOptions{Included: func(path string) { got = append(got, path) }}
+	if _, err := readResumeFromFile(filepath.Join(dir, "a.yaml"), opts); err == nil {
+		t.Fatal("expected an error reading a resume with a missing include")
+	}
+
+	// Missing files are reported too, so that their creation can be noticed.
+	want := []string{
+		filepath.Join(dir, "b.yaml"),
+		filepath.Join(dir, "sub", "c.yaml"),
+		filepath.Join(dir, "sub", "missing.yaml"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("included files = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nilium/resify/render"
+)
+
+// starterIndex is the index template written by the init command. It's the same as the example in the package docs.
+const starterIndex = `<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="utf-8">
+    <title>{{ .Me.Chosen }}: Resume</title>
+</head>
+<body>
+    <h1>{{ .Me.Chosen }}</h1>
+    {{ with or .Summary .Meta.statement }}<p>{{ linkify . }}</p>{{ end }}
+
+    <h2>Employment</h2>
+    <ul>{{ range $e := .Employment }}
+        <li>
+            <h3>{{ .Title }}</h3>
+            <p>{{ .Where.Name }} ({{ .Where.Line }})</p>
+            <p>{{ .Description | linkify }}</p>
+            {{ if .Highlights }}
+            <ul>{{ range .Highlights }}<li>{{ linkify . }}</li>{{ end }}</ul>
+            {{ end }}
+        </li>
+    {{ end }}</ul>
+
+    <h2>Education</h2>
+    <ul>{{ range $e := .Education }}
+        <li>
+            <h3>{{ .Where.Name }} ({{ .Where.Line }})</h3>
+            <p>{{ .Where.Line }}</p>
+            <p><em>{{ or .Received "No degree" }}.</em></p>
+            {{ if .Fields }}
+            <p>Studied {{ range $nth, $f := .Fields }}{{ if gt $nth 0 }}, {{ end }}<em>{{ . }}</em>{{ end }}</p>
+            {{ end }}
+            <p>{{ .Description | linkify }}</p>
+            {{ if .Highlights }}
+            <ul>{{ range .Highlights }}<li>{{ linkify . }}</li>{{ end }}</ul>
+            {{ end }}
+        </li>
+    {{ end }}</ul>
+</body>
+</html>
+`
+
+// starterLink is the link template written by the init command. It defines "link" the same as the default HTML link
+// template so that it can be modified.
+const starterLink = `{{ define "link" }}` + render.DefaultHTMLLink + `{{ end }}
+`
+
+// starterResume is the path of the example resume written by the init command.
+const starterResume = "resume.yaml"
+
+// initProject writes a starter index and link template with the extension ext to dataDir and an example resume to
+// starterResume. If force is false and any of those files already exist, nothing is written. Errors are logged before being
+// returned.
+func initProject(ext string, force bool) error {
+	var resume bytes.Buffer
+	if err := generateYAML(&resume, false); err != nil {
+		log.Println("cannot generate example resume:", err)
+		return err
+	}
+
+	files := []struct {
+		path string
+		data string
+	}{
+		{filepath.Join(dataDir, "index"+ext), starterIndex},
+		{filepath.Join(dataDir, "link"+ext), starterLink},
+		{starterResume, resume.String()},
+	}
+
+	if !force {
+		var exist []string
+		for _, f := range files {
+			if _, err := os.Stat(f.path); err == nil {
+				exist = append(exist, f.path)
+			} else if !os.IsNotExist(err) {
+				log.Printf("cannot check %s: %v", f.path, err)
+				return err
+			}
+		}
+		if len(exist) > 0 {
+			err := fmt.Errorf("refusing to overwrite existing files (use -force to overwrite): %s", strings.Join(exist, ", "))
+			log.Println(err)
+			return err
+		}
+	}
+
+	for _, f := range files {
+		if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+			log.Printf("cannot create directory for %s: %v", f.path, err)
+			return err
+		}
+		if err := ioutil.WriteFile(f.path, []byte(f.data), 0644); err != nil {
+			log.Printf("cannot write %s: %v", f.path, err)
+			return err
+		}
+		infof("wrote %s", f.path)
+	}
+	return nil
+}
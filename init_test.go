@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInitProject(t *testing.T) {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	defer func(d string) { dataDir = d }(dataDir)
+	dataDir = "templates"
+
+	if err := initProject(".tem", false); err != nil {
+		t.Fatalf("unexpected error initializing: %v", err)
+	}
+
+	// The starter files must be enough to render the example resume.
+	renderer, err := newRenderer(false, ".tem", "")
+	if err != nil {
+		t.Fatalf("cannot load starter templates: %v", err)
+	}
+	resume, err := readResumeFromFile(starterResume, readOptions{})
+	if err != nil {
+		t.Fatalf("cannot read example resume: %v", err)
+	}
+	if err = renderer.Render(ioutil.Discard, resume); err != nil {
+		t.Errorf("cannot render example resume: %v", err)
+	}
+
+	// Resume files from before summaries existed still have theirs rendered, without -normalize.
+	if err := ioutil.WriteFile("old.yaml", []byte("me: {chosen: Jane}\nstatement: Builds servers.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if resume, err = readResumeFromFile("old.yaml", readOptions{}); err != nil {
+		t.Fatalf("cannot read old.yaml: %v", err)
+	}
+	var buf bytes.Buffer
+	if err = renderer.Render(&buf, resume); err != nil {
+		t.Errorf("cannot render old.yaml: %v", err)
+	} else if !strings.Contains(buf.String(), "Builds servers.") {
+		t.Errorf("expected the statement of old.yaml to be rendered as its summary; got:\n%s", buf.String())
+	}
+
+	index := filepath.Join("templates", "index.tem")
+	if err := ioutil.WriteFile(index, []byte("edited"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := initProject(".tem", false); err == nil {
+		t.Error("expected error initializing over existing files")
+	} else if b, _ := ioutil.ReadFile(index); string(b) != "edited" {
+		t.Errorf("existing %s was overwritten without force", index)
+	}
+
+	if err := initProject(".tem", true); err != nil {
+		t.Errorf("unexpected error initializing with force: %v", err)
+	} else if b, _ := ioutil.ReadFile(index); string(b) != starterIndex {
+		t.Errorf("existing %s was not overwritten with force", index)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// resumeExts are the extensions of the resume files found in directories given to render.
+var resumeExts = []string{".yaml", ".yml", ".toml"}
+
+// expandInputs returns the resume files named by args, in order. Files and stdin ("-" or empty) are kept as they are.
+// Directories are replaced by the resume files in them, sorted by path, including those in subdirectories if recursive is
+// true. Anything else is expanded as a glob pattern by filepath.Glob, with directories it matches expanded in turn. It's an
+// error for an argument to be none of these, or for a directory or pattern to contain no resume files.
+func expandInputs(args []string, recursive bool) ([]string, error) {
+	inputs := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == "-" || arg == "" {
+			inputs = append(inputs, arg)
+			continue
+		}
+
+		paths := []string{arg}
+		if _, err := os.Stat(arg); os.IsNotExist(err) {
+			matches, err := filepath.Glob(arg)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", arg, err)
+			} else if len(matches) == 0 {
+				return nil, fmt.Errorf("%s: no such file, directory, or matching pattern", arg)
+			}
+			paths = matches
+		}
+
+		n := len(inputs)
+		for _, path := range paths {
+			files, err := resumeFiles(path, recursive)
+			if err != nil {
+				return nil, err
+			}
+			inputs = append(inputs, files...)
+		}
+		if len(inputs) == n {
+			return nil, fmt.Errorf("%s: no resume files found", arg)
+		}
+	}
+	return inputs, nil
+}
+
+// resumeFiles returns path if it's a file, or the resume files (see resumeExts) in it, sorted by path, if it's a directory.
+// Subdirectories are only searched if recursive is true.
+func resumeFiles(path string, recursive bool) ([]string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	} else if !fi.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != path && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isResumeFile(p) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// isResumeFile returns whether path has one of resumeExts.
+func isResumeFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range resumeExts {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
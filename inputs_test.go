@@ -0,0 +1,55 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpandInputs(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"one.yaml":               "",
+		"two.yml":                "",
+		"three.toml":             "",
+		"notes.txt":              "",
+		"variants/a.yaml":        "",
+		"variants/b.yaml":        "",
+		"variants/nested/c.yaml": "",
+		"empty/notes.txt":        "",
+	})
+	path := func(name string) string { return filepath.Join(dir, filepath.FromSlash(name)) }
+
+	table := []struct {
+		args      []string
+		recursive bool
+		want      []string
+	}{
+		{[]string{"-", path("notes.txt")}, false, []string{"-", path("notes.txt")}},
+		{[]string{dir}, false, []string{path("one.yaml"), path("three.toml"), path("two.yml")}},
+		{[]string{path("variants")}, false, []string{path("variants/a.yaml"), path("variants/b.yaml")}},
+		{[]string{path("variants")}, true, []string{path("variants/a.yaml"), path("variants/b.yaml"), path("variants/nested/c.yaml")}},
+		{[]string{path("variants/*.yaml"), path("one.yaml")}, false, []string{path("variants/a.yaml"), path("variants/b.yaml"), path("one.yaml")}},
+		{[]string{path("*/nested")}, false, []string{path("variants/nested/c.yaml")}},
+	}
+
+	for _, e := range table {
+		got, err := expandInputs(e.args, e.recursive)
+		if err != nil {
+			t.Errorf("unexpected error expanding %q: %v", e.args, err)
+		} else if !reflect.DeepEqual(got, e.want) {
+			t.Errorf("expandInputs(%q, %t) = %q; want %q", e.args, e.recursive, got, e.want)
+		}
+	}
+
+	for _, args := range [][]string{
+		{path("missing.yaml")},
+		{path("*.json")},
+		{path("empty")},
+		{path("[")},
+	} {
+		if got, err := expandInputs(args, true); err == nil {
+			t.Errorf("expected error expanding %q; got %q", args, got)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+// renderResult is the result of rendering a single input.
+type renderResult struct {
+	b   []byte
+	err error
+}
+
+// renderConcurrently calls render for each index in [0, n), in order, using up to jobs goroutines at once. It returns a
+// channel for each index that receives its result once render returns. Once done is closed, no further calls to render are
+// started, and the channels of indices that weren't rendered never receive a result.
+func renderConcurrently(n, jobs int, render func(i int) ([]byte, error), done <-chan struct{}) []<-chan renderResult {
+	results := make([]chan renderResult, n)
+	recv := make([]<-chan renderResult, n)
+	for i := range results {
+		results[i] = make(chan renderResult, 1)
+		recv[i] = results[i]
+	}
+
+	indices := make(chan int)
+	go func() {
+		defer close(indices)
+		for i := 0; i < n; i++ {
+			select {
+			case indices <- i:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for w := 0; w < jobs; w++ {
+		go func() {
+			for i := range indices {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				b, err := render(i)
+				results[i] <- renderResult{b, err}
+			}
+		}()
+	}
+
+	return recv
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRenderConcurrently(t *testing.T) {
+	const n = 20
+	errOdd := errors.New("odd")
+
+	done := make(chan struct{})
+	defer close(done)
+
+	results := renderConcurrently(n, 4, func(i int) ([]byte, error) {
+		if i%2 == 1 {
+			return nil, errOdd
+		}
+		return []byte(fmt.Sprint(i)), nil
+	}, done)
+
+	if len(results) != n {
+		t.Fatalf("len(results) = %d; want %d", len(results), n)
+	}
+
+	for i, ch := range results {
+		r := <-ch
+		if i%2 == 1 {
+			if r.err != errOdd {
+				t.Errorf("result %d error = %v; want %v", i, r.err, errOdd)
+			}
+		} else if string(r.b) != fmt.Sprint(i) || r.err != nil {
+			t.Errorf("result %d = %q, %v; want %q", i, r.b, r.err, fmt.Sprint(i))
+		}
+	}
+}
+
+func TestRenderConcurrentlyDone(t *testing.T) {
+	var calls int32
+	done := make(chan struct{})
+	results := renderConcurrently(10, 1, func(i int) ([]byte, error) {
+		atomic.AddInt32(&calls, 1)
+		if i == 2 {
+			close(done)
+		}
+		return nil, nil
+	}, done)
+
+	<-results[2]
+	for _, ch := range results[3:] {
+		select {
+		case <-ch:
+			t.Fatal("received result for input rendered after done was closed")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Errorf("render called %d times; want 3", got)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/nilium/resify/rtype"
+)
+
+// exportJSONResume is the -export format for the JSON Resume schema (https://jsonresume.org/schema/).
+const exportJSONResume = "jsonresume"
+
+// jsonResume and the types below are the subset of the JSON Resume schema that rtype.Resume can be mapped onto. Fields
+// with no equivalent in rtype are left out.
+type jsonResume struct {
+	Basics       jsonResumeBasics        `json:"basics"`
+	Work         []jsonResumeWork        `json:"work,omitempty"`
+	Education    []jsonResumeEducation   `json:"education,omitempty"`
+	Awards       []jsonResumeAward       `json:"awards,omitempty"`
+	Publications []jsonResumePublication `json:"publications,omitempty"`
+	References   []jsonResumeReference   `json:"references,omitempty"`
+}
+
+type jsonResumeBasics struct {
+	Name     string              `json:"name,omitempty"`
+	Email    string              `json:"email,omitempty"`
+	Phone    string              `json:"phone,omitempty"`
+	Image    string              `json:"image,omitempty"`
+	Summary  string              `json:"summary,omitempty"`
+	Profiles []jsonResumeProfile `json:"profiles,omitempty"`
+}
+
+type jsonResumeProfile struct {
+	Network string `json:"network,omitempty"`
+	URL     string `json:"url,omitempty"`
+}
+
+type jsonResumeWork struct {
+	Name       string   `json:"name,omitempty"`
+	Location   string   `json:"location,omitempty"`
+	Position   string   `json:"position,omitempty"`
+	StartDate  string   `json:"startDate,omitempty"`
+	EndDate    string   `json:"endDate,omitempty"`
+	Summary    string   `json:"summary,omitempty"`
+	Highlights []string `json:"highlights,omitempty"`
+}
+
+type jsonResumeEducation struct {
+	Institution string `json:"institution,omitempty"`
+	Area        string `json:"area,omitempty"`
+	StudyType   string `json:"studyType,omitempty"`
+	StartDate   string `json:"startDate,omitempty"`
+	EndDate     string `json:"endDate,omitempty"`
+}
+
+type jsonResumeAward struct {
+	Title   string `json:"title,omitempty"`
+	Date    string `json:"date,omitempty"`
+	Awarder string `json:"awarder,omitempty"`
+	Summary string `json:"summary,omitempty"`
+}
+
+type jsonResumePublication struct {
+	Name        string `json:"name,omitempty"`
+	Publisher   string `json:"publisher,omitempty"`
+	ReleaseDate string `json:"releaseDate,omitempty"`
+	URL         string `json:"url,omitempty"`
+	Summary     string `json:"summary,omitempty"`
+}
+
+type jsonResumeReference struct {
+	Name      string `json:"name,omitempty"`
+	Reference string `json:"reference,omitempty"`
+}
+
+// jsonResumeDate formats t as a JSON Resume date. Zero times are empty, so ongoing ranges have no end date.
+func jsonResumeDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+// toJSONResume maps resume onto the JSON Resume schema. A photo is only exported if it's a URL, since a path is meaningless
+// without the templates directory. Profiles are named by their label or, if they have none, their
+// key, and are listed in order (see rtype.Profiles.Ordered). Education fields of study are joined into a single area. Awards
+// and publications are dated by the start of their date range. A reference's note is its reference text; its relationship and
+// contact details have no place in JSON Resume and are left out, as are education highlights, which JSON Resume lacks.
+func toJSONResume(resume rtype.Resume) jsonResume {
+	jr := jsonResume{
+		Basics: jsonResumeBasics{
+			Name:    resume.Me.Name(),
+			Email:   resume.Me.Email,
+			Phone:   resume.Me.Phone,
+			Summary: resume.Summary,
+		},
+	}
+
+	if resume.Me.PhotoIsURL() {
+		jr.Basics.Image = resume.Me.Photo
+	}
+
+	for _, p := range resume.Profiles.Ordered() {
+		network := p.Label
+		if len(network) == 0 {
+			network = p.Key
+		}
+		jr.Basics.Profiles = append(jr.Basics.Profiles, jsonResumeProfile{Network: network, URL: p.URL})
+	}
+
+	for _, e := range resume.Employment {
+		jr.Work = append(jr.Work, jsonResumeWork{
+			Name:       e.Where.Name,
+			Location:   e.Where.Line(),
+			Position:   e.Title,
+			StartDate:  jsonResumeDate(e.When.From),
+			EndDate:    jsonResumeDate(e.When.To),
+			Summary:    e.Description,
+			Highlights: e.Highlights,
+		})
+	}
+
+	for _, e := range resume.Education {
+		jr.Education = append(jr.Education, jsonResumeEducation{
+			Institution: e.Where.Name,
+			Area:        strings.Join(e.Fields, ", "),
+			StudyType:   e.Received,
+			StartDate:   jsonResumeDate(e.When.From),
+			EndDate:     jsonResumeDate(e.When.To),
+		})
+	}
+
+	for _, e := range resume.Awards {
+		jr.Awards = append(jr.Awards, jsonResumeAward{
+			Title:   e.Title,
+			Date:    jsonResumeDate(e.Date.From),
+			Awarder: e.Awarder,
+			Summary: e.Summary,
+		})
+	}
+
+	for _, e := range resume.Publications {
+		jr.Publications = append(jr.Publications, jsonResumePublication{
+			Name:        e.Title,
+			Publisher:   e.Publisher,
+			ReleaseDate: jsonResumeDate(e.Date.From),
+			URL:         e.URL,
+			Summary:     e.Summary,
+		})
+	}
+
+	for _, e := range resume.References {
+		jr.References = append(jr.References, jsonResumeReference{
+			Name:      e.Name,
+			Reference: e.Note,
+		})
+	}
+
+	return jr
+}
+
+// marshalJSONResume returns the resume as JSON Resume JSON. If indent is true, the JSON is pretty-printed.
+func marshalJSONResume(resume rtype.Resume, indent bool) ([]byte, error) {
+	if indent {
+		return json.MarshalIndent(toJSONResume(resume), "", "  ")
+	}
+	return json.Marshal(toJSONResume(resume))
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/nilium/resify/rtype"
+)
+
+func TestMarshalJSONResume(t *testing.T) {
+	ongoing, err := rtype.NewDateRange("2016-03", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resume := rtype.Resume{
+		Me:      rtype.Me{Chosen: "Jane", Email: "jane@example.com", Photo: "https://example.com/jane.jpg"},
+		Summary: "Builds servers.",
+		Profiles: rtype.Profiles{
+			Profile: map[string]rtype.Profile{"github": {URL: "https://github.com/jane"}},
+		},
+		Employment: []rtype.Employment{{
+			Title:      "Engineer",
+			When:       ongoing,
+			Where:      rtype.Place{Name: "Foobiz", City: "Deadtown", Region: "AL"},
+			Highlights: []string{"Shipped it"},
+			Meta:       rtype.Meta{"hidden": true},
+		}},
+		Publications: []rtype.Publication{{
+			Title:     "Throughput",
+			Publisher: "Journal",
+			Date:      ongoing,
+			URL:       "https://example.com/paper",
+		}},
+		References: []rtype.Reference{{Name: "Ref", Relationship: "Manager", Note: "Good."}},
+	}
+
+	b, err := marshalJSONResume(resume, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `{"basics":{"name":"Jane","email":"jane@example.com","image":"https://example.com/jane.jpg","summary":"Builds servers.","profiles":[{"network":"github","url":"https://github.com/jane"}]},` +
+		`"work":[{"name":"Foobiz","location":"Deadtown, AL","position":"Engineer","startDate":"2016-03-01","highlights":["Shipped it"]}],` +
+		`"publications":[{"name":"Throughput","publisher":"Journal","releaseDate":"2016-03-01","url":"https://example.com/paper"}],` +
+		`"references":[{"name":"Ref","reference":"Good."}]}`
+	if string(b) != want {
+		t.Errorf("unexpected JSON Resume:\ngot  %s\nwant %s", b, want)
+	}
+}
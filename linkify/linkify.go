@@ -0,0 +1,263 @@
+// Package linkify parses and renders the links used in resify text: links of the form ((URL label)) and [label](URL), as well
+// as bare http(s) URLs and email addresses. Links are rendered with a "link" template given by the caller, which is executed
+// with a Link.
+package linkify // import "github.com/nilium/resify/linkify"
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const whitespace = "\r\n\t "
+
+// ErrNotALink is returned by Parse when given text that isn't a link.
+var ErrNotALink = errors.New("not a link")
+
+// Template is a set of templates that links can be rendered with, such as a *text/template.Template or an
+// *html/template.Template. Links are rendered by executing its "link" template.
+type Template interface {
+	ExecuteTemplate(io.Writer, string, interface{}) error
+}
+
+// linkFormat matches links of the form ((URL label)) and [label](URL).
+var linkFormat = regexp.MustCompile(`\(\(.+?\)\)|\[[^\[\]]*\]\([^()]*\)`)
+
+// autolinkFormat matches bare http(s) URLs and email addresses. Trailing punctuation is trimmed from URL matches by
+// autolinkURL.
+var autolinkFormat = regexp.MustCompile(`\bhttps?://[^\s<>"]+|\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`)
+
+// Link is a parsed link. It's the data given to the "link" template when rendering a link.
+type Link struct {
+	URL   *url.URL
+	Label string
+}
+
+// Parse parses a link of the form ((URL label)) or [label](URL). In either form, the label may contain spaces and is
+// optional. If there is no label, the URL's hostname (sans port) and path is used as the label. If the URL has no hostname
+// nor path, besides that being weird, the full URL will be used.
+//
+// If src isn't a link, ErrNotALink is returned.
+func Parse(src string) (link Link, err error) {
+	if strings.HasPrefix(src, "[") {
+		return parseMarkdownLink(src)
+	}
+
+	if !strings.HasPrefix(src, "((") || !strings.HasSuffix(src, "))") || len(src) <= 4 {
+		return Link{}, ErrNotALink
+	}
+
+	src = strings.Trim(src[2:len(src)-2], whitespace)
+	if len(src) == 0 {
+		return Link{}, ErrNotALink
+	}
+
+	components := strings.SplitN(src, " ", 2)
+	label := ""
+	if len(components) > 1 {
+		label = components[1]
+	}
+
+	return New(components[0], label)
+}
+
+// parseMarkdownLink parses a link of the form [label](URL). Anything following the URL inside the parentheses, such as a
+// title, is ignored.
+func parseMarkdownLink(src string) (link Link, err error) {
+	mid := strings.Index(src, "](")
+	if !strings.HasPrefix(src, "[") || !strings.HasSuffix(src, ")") || mid == -1 {
+		return Link{}, ErrNotALink
+	}
+
+	label := src[1:mid]
+	fields := strings.Fields(src[mid+2 : len(src)-1])
+	if len(fields) == 0 {
+		return Link{}, ErrNotALink
+	}
+
+	return New(fields[0], label)
+}
+
+// Labels returns s with each link of the form ((URL label)) or [label](URL) replaced by its label (see Parse), such as to
+// measure text without its link markup. Text that looks like a link but can't be parsed is left as is.
+func Labels(s string) string {
+	return linkFormat.ReplaceAllStringFunc(s, func(p string) string {
+		if link, err := Parse(p); err == nil {
+			return link.Label
+		}
+		return p
+	})
+}
+
+// New returns a Link for the given URL and label. If the label is empty, the URL's host and path are used as the label, or
+// the URL itself if it has neither.
+func New(rawURL, label string) (link Link, err error) {
+	rawURL = strings.Trim(rawURL, whitespace)
+	link.URL, err = url.Parse(rawURL)
+	if err != nil {
+		return Link{}, err
+	}
+
+	link.Label = strings.Trim(label, whitespace)
+	if len(link.Label) == 0 {
+		link.Label = link.URL.Host + link.URL.Path
+
+		if len(link.Label) == 0 {
+			link.Label = rawURL
+		}
+	}
+
+	return link, err
+}
+
+// autolinkURL returns a Link for a bare URL or email address matched by autolinkFormat, with the URL itself as its label.
+// Email addresses are given a mailto: URL. Any trailing punctuation not likely to be part of the URL is returned as the
+// remainder, to be kept outside the link.
+func autolinkURL(p string) (link Link, rest string, err error) {
+	raw := p
+	if strings.Contains(p, "://") {
+		raw = strings.TrimRight(p, ".,;:!?)]}'")
+		rest = p[len(raw):]
+		link.URL, err = url.Parse(raw)
+	} else {
+		link.URL = &url.URL{Scheme: "mailto", Opaque: raw}
+	}
+
+	if err != nil {
+		return Link{}, p, err
+	}
+
+	link.Label = raw
+	return link, rest, nil
+}
+
+// Linker renders links using the "link" template of its Template.
+type Linker struct {
+	// Template defines the "link" template that links are rendered with. It must not be nil.
+	Template Template
+
+	// Escape escapes text written around rendered links, such as html/template.HTMLEscapeString for HTML output. If nil,
+	// text is not escaped.
+	Escape func(string) string
+
+	// Autolink is whether Linkify also links bare http(s) URLs and email addresses.
+	Autolink bool
+
+	// Disabled is whether Linkify leaves links alone, only escaping the text given to it.
+	Disabled bool
+
+	// Pattern, if not nil, matches the links converted by Linkify instead of the default pattern, which matches links of
+	// the form ((URL label)) and [label](URL). Text it matches must still be a link in one of those forms (see Parse), so
+	// it can only be stricter than the default, such as to only convert ((URL label)) links.
+	Pattern *regexp.Regexp
+
+	// Warnf, if not nil, is called with a message for each link that cannot be parsed or rendered.
+	Warnf func(format string, args ...interface{})
+
+	// Debugf, if not nil, is called with a message for each link rendered.
+	Debugf func(format string, args ...interface{})
+}
+
+func (l *Linker) escape(s string) string {
+	if l.Escape == nil {
+		return s
+	}
+	return l.Escape(s)
+}
+
+// RenderLink renders a link of the form ((URL label)) or [label](URL) (see Parse). If a link cannot be rendered, the label
+// text alone is returned. If the link cannot be parsed at all, the original string is returned. In either case, the returned
+// text is not escaped and must be escaped by the caller.
+func (l *Linker) RenderLink(p string) (string, error) {
+	link, err := Parse(p)
+	if err != nil {
+		if err != ErrNotALink && l.Warnf != nil {
+			l.Warnf("error parsing link %q: %v", p, err)
+		}
+		return p, err
+	}
+
+	return l.Format(link)
+}
+
+// Format renders link using the "link" template. If the link cannot be rendered, its label is returned.
+func (l *Linker) Format(link Link) (string, error) {
+	var buf bytes.Buffer
+	if err := l.Template.ExecuteTemplate(&buf, "link", link); err != nil {
+		if l.Warnf != nil {
+			l.Warnf("error rendering link: %v", err)
+		}
+		return link.Label, err
+	}
+
+	if l.Debugf != nil {
+		l.Debugf("rendered link to %s labeled %q", link.URL, link.Label)
+	}
+	return buf.String(), nil
+}
+
+// Linkify converts any links of the format ((URL label)) or [label](URL) in s to links rendered by the "link" template and
+// returns the result. Non-link text is escaped and written around the rendered links. If a link cannot be rendered, its
+// fallback text (see RenderLink) is escaped and used in its place.
+//
+// If Autolink is true, bare http(s) URLs and email addresses in the non-link text are also rendered as links, using the URL
+// or address as the label. If Disabled is true, s is only escaped.
+//
+// Rendered links are written directly into the result rather than being substituted back into the escaped text, so no
+// text in s can be mistaken for a rendered link.
+func (l *Linker) Linkify(s string) string {
+	if l.Disabled {
+		return l.escape(s)
+	}
+
+	pattern := l.Pattern
+	if pattern == nil {
+		pattern = linkFormat
+	}
+
+	var buf bytes.Buffer
+	last := 0
+	for _, m := range pattern.FindAllStringIndex(s, -1) {
+		buf.WriteString(l.autolinkText(s[last:m[0]]))
+		if r, err := l.RenderLink(s[m[0]:m[1]]); err != nil {
+			buf.WriteString(l.escape(r))
+		} else {
+			buf.WriteString(r)
+		}
+		last = m[1]
+	}
+	buf.WriteString(l.autolinkText(s[last:]))
+	return buf.String()
+}
+
+// autolinkText escapes s. If Autolink is true, any bare URLs or email addresses in s are rendered as links in the result.
+func (l *Linker) autolinkText(s string) string {
+	if !l.Autolink {
+		return l.escape(s)
+	}
+
+	var buf bytes.Buffer
+	last := 0
+	for _, m := range autolinkFormat.FindAllStringIndex(s, -1) {
+		p := s[m[0]:m[1]]
+		link, rest, err := autolinkURL(p)
+		if err != nil {
+			continue
+		}
+
+		r, err := l.Format(link)
+		if err != nil {
+			continue
+		}
+
+		buf.WriteString(l.escape(s[last:m[0]]))
+		buf.WriteString(r)
+		buf.WriteString(l.escape(rest))
+		last = m[1]
+	}
+	buf.WriteString(l.escape(s[last:]))
+	return buf.String()
+}
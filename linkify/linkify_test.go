@@ -0,0 +1,226 @@
+package linkify
+
+import (
+	"bytes"
+	"fmt"
+	htmlt "html/template"
+	"log"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	textt "text/template"
+)
+
+func TestParseLink(t *testing.T) {
+	table := []struct {
+		in    string
+		label string
+		ok    bool
+	}{
+		{"((http://url-to-thing.com/path?query#fragment))", "url-to-thing.com/path", true},
+		{"((http://url-to-thing.com/path?query#fragment label))", "label", true},
+		{"((http://url-to-thing.com/path?query#fragment multi-word label))", "multi-word label", true},
+		{"(( http://url-to-thing.com/path?query#fragment multi-word label ))", "multi-word label", true},
+		{"((not-a-useful-url multi-word label))", "multi-word label", true},
+		{"not a link", "multi-word label", false},
+		{"(())", "", false},
+		{"((\t\n\r ))", "", false},
+		{"(* what are you doing this isn't applescript *)", "", false},
+		{"((", "", false},
+		{"))", "", false},
+		{"(())))", "))", true}, // The most bizarre things are URLs.
+		{"((f))", "f", true},
+		{"(( f:// ))", "f://", true},
+		{"((f://host))", "host", true},
+		{"((f://host {}))", "{}", true},
+		{"((f://host%20 {}))", "{}", false},
+		{"((f://host/%20 {}))", "{}", true},
+	}
+
+	for _, e := range table {
+		switch l, err := Parse(e.in); {
+		case (err == nil) != e.ok:
+			t.Errorf("failed to correctly parse %q: %v\n%v\n%q", e.in, err, l.URL, l.Label)
+		case err != nil && !e.ok:
+			// pass -- we don't care what the label is at this point
+		case l.Label != e.label:
+			t.Errorf("expected label %q; got %q for %q\n%v\n%q", e.label, l.Label, e.in, l.URL, l.Label)
+		}
+	}
+}
+
+func TestParseMarkdownLink(t *testing.T) {
+	table := []struct {
+		in    string
+		label string
+		url   string
+		ok    bool
+	}{
+		{"[label](http://url-to-thing.com/path?query#fragment)", "label", "http://url-to-thing.com/path?query#fragment", true},
+		{"[multi-word label](http://url-to-thing.com/path?query=1&b=2#fragment)", "multi-word label", "http://url-to-thing.com/path?query=1&b=2#fragment", true},
+		{"[ multi-word label ]( http://url-to-thing.com/path )", "multi-word label", "http://url-to-thing.com/path", true},
+		{`[label](http://url-to-thing.com/path "title")`, "label", "http://url-to-thing.com/path", true},
+		{"[](http://url-to-thing.com/path?query#fragment)", "url-to-thing.com/path", "http://url-to-thing.com/path?query#fragment", true},
+		{"[](f:)", "f:", "f:", true},
+		{"[label]()", "", "", false},
+		{"[label]( \t )", "", "", false},
+		{"[label]", "", "", false},
+		{"[label](", "", "", false},
+		{"label](url)", "", "", false},
+		{"[label](f://host%20)", "", "", false},
+	}
+
+	for _, e := range table {
+		switch l, err := Parse(e.in); {
+		case (err == nil) != e.ok:
+			t.Errorf("failed to correctly parse %q: %v\n%v\n%q", e.in, err, l.URL, l.Label)
+		case err != nil && !e.ok:
+			// pass
+		case l.Label != e.label:
+			t.Errorf("expected label %q; got %q for %q", e.label, l.Label, e.in)
+		case l.URL.String() != e.url:
+			t.Errorf("expected URL %q; got %q for %q", e.url, l.URL, e.in)
+		}
+	}
+}
+
+func TestLinkifyAutolink(t *testing.T) {
+	l := Linker{Template: textt.Must(textt.New("link").Parse(`[{{ .Label }}]<{{ .URL }}>`))}
+
+	table := []struct {
+		in, out  string
+		autolink bool
+	}{
+		{"see https://example.com/path.", "see [https://example.com/path]<https://example.com/path>.", true},
+		{"mail me@example.com, please", "mail [me@example.com]<mailto:me@example.com>, please", true},
+		{"((https://example.com label)) and http://example.org", "[label]<https://example.com> and [http://example.org]<http://example.org>", true},
+		{"((https://example.com))", "[example.com]<https://example.com>", true},
+		{"((mailto:me@example.com me))", "[me]<mailto:me@example.com>", true},
+		{"see https://example.com/path.", "see https://example.com/path.", false},
+		{"not@a-link", "not@a-link", true},
+		{"[label](https://example.com) and [label]()", "[label]<https://example.com> and [label]()", true},
+	}
+
+	for _, e := range table {
+		l.Autolink = e.autolink
+		if got := l.Linkify(e.in); got != e.out {
+			t.Errorf("Linkify(%q) = %q; expected %q", e.in, got, e.out)
+		}
+	}
+}
+
+func TestLinkifyPlaceholderText(t *testing.T) {
+	l := Linker{
+		Template: textt.Must(textt.New("link").Parse(`<a href="{{ .URL }}">{{ .Label }}</a>`)),
+		Escape:   htmlt.HTMLEscapeString,
+		Autolink: true,
+	}
+
+	// Literal text that looks like the placeholders linkify once used must come through untouched (but escaped).
+	hash := "$" + strings.Repeat("0123456789abcdef", 2) + "01234567$"
+	table := []struct {
+		in, out string
+	}{
+		{hash, hash},
+		{hash + " ((https://example.com label))", hash + ` <a href="https://example.com">label</a>`},
+		{"((https://example.com " + hash + ")) " + hash, `<a href="https://example.com">` + hash + "</a> " + hash},
+		{"costs $5 & $6 ((https://example.com a)) ((https://example.com a))", `costs $5 &amp; $6 <a href="https://example.com">a</a> <a href="https://example.com">a</a>`},
+		{"$da39a3ee5e6b4b0d3255bfef95601890afd80709$ <b>", "$da39a3ee5e6b4b0d3255bfef95601890afd80709$ &lt;b&gt;"},
+	}
+
+	for _, e := range table {
+		if got := l.Linkify(e.in); got != e.out {
+			t.Errorf("Linkify(%q) = %q; expected %q", e.in, got, e.out)
+		}
+	}
+}
+
+func TestLinkifyEscapesFallback(t *testing.T) {
+	l := Linker{Escape: htmlt.HTMLEscapeString}
+
+	table := []struct {
+		link string
+		in   string
+		out  string
+	}{
+		{`<a href="{{ .URL }}">{{ .Label }}</a>`, "[<script>alert(1)</script>]()", "[&lt;script&gt;alert(1)&lt;/script&gt;]()"},
+		{`<a href="{{ .URL }}">{{ .Label }}</a>`, "((f://host%20 <script>x</script>))", "((f://host%20 &lt;script&gt;x&lt;/script&gt;))"},
+		// The link template fails to execute, so only the label is used.
+		{`{{ .Missing }}`, "((https://example.com <script>x</script>))", "&lt;script&gt;x&lt;/script&gt;"},
+		{`{{ .Missing }}`, "[<script>x</script>](https://example.com)", "&lt;script&gt;x&lt;/script&gt;"},
+	}
+
+	for _, e := range table {
+		l.Template = htmlt.Must(htmlt.New("link").Parse(e.link))
+		if got := l.Linkify(e.in); got != e.out {
+			t.Errorf("Linkify(%q) = %q; expected %q", e.in, got, e.out)
+		}
+	}
+}
+
+func TestLabels(t *testing.T) {
+	table := []struct {
+		in, out string
+	}{
+		{"plain text", "plain text"},
+		{"see ((https://example.com my site)) and [docs](https://example.org/docs)", "see my site and docs"},
+		{"((https://example.com/path))", "example.com/path"},
+		{"[label]() stays", "[label]() stays"},
+	}
+
+	for _, e := range table {
+		if got := Labels(e.in); got != e.out {
+			t.Errorf("Labels(%q) = %q; expected %q", e.in, got, e.out)
+		}
+	}
+}
+
+func TestLinkifyDisabledAndPattern(t *testing.T) {
+	tmpl := textt.Must(textt.New("link").Parse(`<a href="{{ .URL }}">{{ .Label }}</a>`))
+	in := "<b> ((https://example.com a)) [b](https://example.org) https://example.net"
+
+	table := []struct {
+		l   Linker
+		out string
+	}{
+		{Linker{Template: tmpl, Escape: htmlt.HTMLEscapeString, Autolink: true, Disabled: true},
+			"&lt;b&gt; ((https://example.com a)) [b](https://example.org) https://example.net"},
+		{Linker{Template: tmpl, Disabled: true}, in},
+		{Linker{Template: tmpl, Escape: htmlt.HTMLEscapeString, Pattern: regexp.MustCompile(`\(\(.+?\)\)`)},
+			`&lt;b&gt; <a href="https://example.com">a</a> [b](https://example.org) https://example.net`},
+		// Matches that aren't links are left as they are.
+		{Linker{Template: tmpl, Pattern: regexp.MustCompile(`<b>`)}, in},
+	}
+
+	for _, e := range table {
+		if got := e.l.Linkify(in); got != e.out {
+			t.Errorf("Linkify(%q) = %q; expected %q", in, got, e.out)
+		}
+	}
+}
+
+func TestLinkifyWarnf(t *testing.T) {
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	var warnings []string
+	l := Linker{
+		Template: textt.Must(textt.New("link").Parse(`{{ .Missing }}`)),
+		Warnf:    func(format string, args ...interface{}) { warnings = append(warnings, fmt.Sprintf(format, args...)) },
+	}
+
+	l.Linkify("((https://example.com Fine)) ((f://host%20 Bad)) not a link")
+	want := []string{
+		`error rendering link: template: link:1:3: executing "link" at <.Missing>: can't evaluate field Missing in type linkify.Link`,
+		`error parsing link "((f://host%20 Bad))": parse "f://host%20": invalid URL escape "%20"`,
+	}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("warnings = %q; expected %q", warnings, want)
+	}
+	if logged.Len() > 0 {
+		t.Errorf("expected nothing written to the standard logger; got %q", logged.String())
+	}
+}
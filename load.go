@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// delims are the left and right action delimiters used to parse templates. Empty delimiters are the defaults, "{{" and "}}".
+var delims [2]string
+
+// parseDelims parses the value of -delims, which must be a left and right delimiter separated by whitespace, as in "[[ ]]".
+// An empty value gives the default delimiters.
+func parseDelims(s string) ([2]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return [2]string{}, nil
+	}
+
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return [2]string{}, fmt.Errorf("delimiters must be a left and right delimiter separated by a space, as in \"[[ ]]\"; got %q", s)
+	}
+	return [2]string{fields[0], fields[1]}, nil
+}
+
+// findDataDir returns the path of the directory dir, relative to the working directory or, if it isn't there, to the nearest
+// parent of the working directory that has it. If no such directory is found, or dir is absolute, dir is returned.
+func findDataDir(dir string) string {
+	if filepath.IsAbs(dir) || isDir(dir) {
+		return dir
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return dir
+	}
+
+	for parent := filepath.Dir(wd); ; parent = filepath.Dir(parent) {
+		if found := filepath.Join(parent, dir); isDir(found) {
+			return found
+		}
+		if parent == filepath.Dir(parent) {
+			return dir
+		}
+	}
+}
+
+// isDir returns whether path is a directory.
+func isDir(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.IsDir()
+}
+
+// normalizeExt returns ext with a leading dot, unless ext is empty.
+func normalizeExt(ext string) string {
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// isTemplatePath returns whether name, as given by -template, is the path of a template file instead of the name of a
+// loaded template. A template file is loaded as render.MainTemplateName. Names beginning with "./", "../", or "/" are
+// paths, as are names containing a path separator that refer to an existing file. "-" is stdin.
+func isTemplatePath(name string) bool {
+	if name == "-" || strings.HasPrefix(name, "./") || strings.HasPrefix(name, "../") || filepath.IsAbs(name) {
+		return true
+	}
+	if strings.ContainsAny(name, "/"+string(filepath.Separator)) {
+		fi, err := os.Stat(name)
+		return err == nil && fi.Mode().IsRegular()
+	}
+	return false
+}
+
+// stdinTemplate holds the main template read from stdin, since stdin can only be read once.
+var stdinTemplate struct {
+	once sync.Once
+	src  string
+	err  error
+}
+
+// readTemplateFile returns the source of the template file at path. If path is "-", the template is read from stdin the
+// first time and the same source is returned after that.
+func readTemplateFile(path string) (string, error) {
+	if path == "-" {
+		stdinTemplate.once.Do(func() {
+			b, err := ioutil.ReadAll(os.Stdin)
+			stdinTemplate.src, stdinTemplate.err = string(b), err
+		})
+		return stdinTemplate.src, stdinTemplate.err
+	}
+
+	b, err := ioutil.ReadFile(path)
+	return string(b), err
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nilium/resify/render"
+	"github.com/nilium/resify/rtype"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, src := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestIsTemplatePath(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"one-off.tem": "x"})
+
+	table := []struct {
+		name string
+		want bool
+	}{
+		{"-", true},
+		{"./one-off.tem", true},
+		{"../one-off.tem", true},
+		{filepath.Join(dir, "one-off.tem"), true},
+		{filepath.Join(dir, "missing.tem"), true},
+		{"index", false},
+		{"index.tem", false},
+		{"partials/header.tem", false},
+		{"", false},
+	}
+
+	for _, e := range table {
+		if got := isTemplatePath(e.name); got != e.want {
+			t.Errorf("isTemplatePath(%q) = %v; expected %v", e.name, got, e.want)
+		}
+	}
+}
+
+func TestNewRendererTemplateFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"templates/style.css": "h1 {}",
+		"one-off.tem":         `{{ embed "style.css" }} {{ linkify "((https://example.com Example))" }}`,
+	})
+
+	defer func(d string) { dataDir = d }(dataDir)
+	dataDir = filepath.Join(dir, "templates")
+
+	renderer, err := newRenderer(true, ".tem", filepath.Join(dir, "one-off.tem"))
+	if err != nil {
+		t.Fatalf("unexpected error loading template file: %v", err)
+	}
+	if name := renderer.Name(); name != render.MainTemplateName {
+		t.Errorf("expected main template %q; got %q", render.MainTemplateName, name)
+	}
+
+	var buf strings.Builder
+	if err = renderer.Render(&buf, rtype.Resume{}); err != nil {
+		t.Fatalf("unexpected error executing template file: %v", err)
+	}
+	if want := "h1 {} Example (https://example.com)"; buf.String() != want {
+		t.Errorf("expected %q; got %q", want, buf.String())
+	}
+}
+
+func TestParseDelims(t *testing.T) {
+	table := []struct {
+		in   string
+		want [2]string
+		ok   bool
+	}{
+		{"", [2]string{}, true},
+		{"[[ ]]", [2]string{"[[", "]]"}, true},
+		{"  <% \t %>  ", [2]string{"<%", "%>"}, true},
+		{"[[", [2]string{}, false},
+		{"[[ ]] {{", [2]string{}, false},
+	}
+
+	for _, e := range table {
+		got, err := parseDelims(e.in)
+		if (err == nil) != e.ok || got != e.want {
+			t.Errorf("parseDelims(%q) = %q, %v; expected %q (ok: %v)", e.in, got, err, e.want, e.ok)
+		}
+	}
+}
+
+func TestFindDataDir(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, dir, map[string]string{
+		"project/templates/index.tem": "x",
+		"project/sub/deeper/file":     "x",
+		"project/sub/templates":       "not a directory",
+	})
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	table := []struct {
+		wd, dir, want string
+	}{
+		{"project", "templates", "templates"},
+		{"project/sub/deeper", "templates", filepath.Join(dir, "project", "templates")},
+		{"project/sub", "templates", filepath.Join(dir, "project", "templates")},
+		{"project/sub/deeper", "missing", "missing"},
+		{"project/sub/deeper", filepath.Join(dir, "abs"), filepath.Join(dir, "abs")},
+	}
+
+	for _, e := range table {
+		if err := os.Chdir(filepath.Join(dir, filepath.FromSlash(e.wd))); err != nil {
+			t.Fatal(err)
+		}
+		if got := findDataDir(e.dir); got != e.want {
+			t.Errorf("findDataDir(%q) in %s = %q; want %q", e.dir, e.wd, got, e.want)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import "log"
+
+// Log levels, set by the -quiet and -verbose flags. Errors are logged at every level.
+const (
+	logQuiet   = iota // Log only errors
+	logNormal         // Also log warnings and progress
+	logVerbose        // Also log templates loaded, files embedded, and links rendered
+)
+
+// logLevel is the level of messages logged by warnf, infof, and debugf.
+var logLevel = logNormal
+
+// warnf logs a non-fatal problem, prefixed by "warning: ", unless logging is quiet.
+func warnf(format string, args ...interface{}) {
+	if logLevel >= logNormal {
+		log.Printf("warning: "+format, args...)
+	}
+}
+
+// infof logs progress unless logging is quiet.
+func infof(format string, args ...interface{}) {
+	if logLevel >= logNormal {
+		log.Printf(format, args...)
+	}
+}
+
+// debugf logs details useful for debugging templates if logging is verbose.
+func debugf(format string, args ...interface{}) {
+	if logLevel >= logVerbose {
+		log.Printf(format, args...)
+	}
+}
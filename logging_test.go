@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"testing"
+)
+
+func TestLogLevels(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	defer func(level int) { logLevel = level }(logLevel)
+
+	table := []struct {
+		level int
+		want  string
+	}{
+		{logQuiet, ""},
+		{logNormal, "warning: w\ni\n"},
+		{logVerbose, "warning: w\ni\nd\n"},
+	}
+
+	flags := log.Flags()
+	log.SetFlags(0)
+	defer log.SetFlags(flags)
+
+	for _, e := range table {
+		buf.Reset()
+		logLevel = e.level
+		warnf("w")
+		infof("i")
+		debugf("d")
+		if buf.String() != e.want {
+			t.Errorf("expected level %d to log %q; got %q", e.level, e.want, buf.String())
+		}
+	}
+}
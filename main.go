@@ -1,18 +1,266 @@
 // Command resify takes a resume YAML file and runs it through a template (by default index.tem), loaded from a templates
-// directory. Templates can optionally emit either HTML-specific or text output. This is specifically for controlling
+// directory. Templates can optionally emit HTML-specific, text, or Markdown output. This is specifically for controlling
 // context-specific escaping in templates.
 //
 //  $ go get github.com/nilium/resify
 //
-// resify understands two commands: 'render' and 'yaml'. If given the render command, it will read any YAML files given on
-// the command line, after the 'render' command, and one by one render them to the output given (by default the standard
-// output).
+// resify understands seven commands: 'render', 'yaml', 'validate', 'serve', 'init', 'schema', and 'version'. If given the render
+// command, it will read any YAML files given on the command line, after the 'render' command, and one by one render them to
+// the output given (by default the standard output).
 //
 // If given the yaml command, resify will write an example YAML file for use with resify to the output. This can be modified
-// for generating resume outputs in any text or HTML-based format.
+// for generating resume outputs in any text or HTML-based format. If -minimal is given, a bare starter file is written
+// instead, with placeholder values and one entry in each section to fill in or delete:
 //
-// resify expects to find templates under pwd/templates with the file extension ".tem". If any templates fail to compile or
-// cannot be rendered, an error is written to standard error and resify returns 1.
+//  $ resify yaml -minimal -o resume.yaml
+//
+// If given the validate command, resify will read each YAML file given and report any problems found in it, such as dates
+// that cannot be parsed, date ranges that end before they start, profile URLs that cannot be parsed or have no scheme, and
+// empty required fields. Each problem is written to standard error as a single line naming the file and the field. If any
+// file has problems, resify returns 3. No templates are loaded when validating.
+//
+// If given the serve command, resify will serve the single YAML file given over HTTP on the address given by -addr (by
+// default ":8080"). Each request to / reads the file again and renders it, so changes show up when the page is
+// reloaded. Templates are only loaded again when a file beneath the templates directory has changed, and requests
+// already being rendered finish with the templates they started with. If rendering fails, the error is returned as a
+// 500 page. Files beneath the templates directory, such as those used with embed, are served under /static/, except for
+// symlinks to files outside of it, the same as for embed:
+//
+//  $ resify serve me.yaml
+//
+// If -no-reload is given to serve, templates are loaded once when resify starts, failing if they can't be, and are never
+// loaded again, such as for serving a finished resume:
+//
+//  $ resify serve -no-reload -addr :80 me.yaml
+//
+// If given the init command, resify will write a starter templates/index.tem (the example template below) and
+// templates/link.tem to the current directory, along with an example resume.yaml, the same as the one written by the yaml
+// command. If any of these files already exist, nothing is written and resify returns 5, unless -force is given:
+//
+//  $ resify init && resify render resume.yaml
+//
+// If given the schema command, resify will write an outline of the data available to templates to the output, derived from
+// the resume types themselves so it's never out of date. Each line names a field as it's used in templates, its key in
+// resume files, and its type, followed by the fields beneath it; methods that templates can call, such as .Me.Name, are
+// listed with their arguments and result. With -json, the outline is written as JSON instead, such as for editor
+// completion:
+//
+//  $ resify schema
+//  .Me (me): Me
+//    .Order (ordered): []string
+//    .Chosen (chosen): string
+//    ...
+//
+// By default, resify logs errors, warnings, and progress (such as while watching or serving) to standard error. If -quiet
+// is given, only errors are logged. If -verbose is given, resify also logs each template loaded, file embedded, and link
+// rendered. The exit status is the same either way.
+//
+// resify exits with one of the following statuses, so that scripts can tell what kind of problem stopped it:
+//
+//  0: Success.
+//  1: Any other failure, such as being interrupted while using a -data-dir tarball.
+//  2: Bad usage: an unknown command, an invalid flag or flag value, or flags that can't be used together.
+//  3: A resume file cannot be read or parsed, or validate found problems in one.
+//  4: Templates cannot be loaded, parsed, or executed, including an output path given by front matter that can't be
+//      parsed.
+//  5: Output or other files cannot be written, such as by init, a -data-dir tarball cannot be extracted, or serve cannot
+//      listen on its address.
+//  6: render -check found an output that is missing or out of date.
+//
+// If given the version command or the -version flag, resify will print its version, the version of Go it was built with,
+// and the VCS revision it was built from, if known, and exit. The version is "dev" unless set at build time with
+// -ldflags "-X main.version=...".
+//
+// By default, the output of every file given to render is written, one after the other, to the output path given by -o.
+// If the output path contains template actions, it's instead used as a pattern to give each file its own output path.
+// The pattern has access to the input's file name as {{.Name}}, its file name without extension as {{.Base}}, and its
+// directory as {{.Dir}} (stdin is named "stdin", in "."). Directories are created as needed, and it's an error for two
+// inputs to have the same output path:
+//
+//  $ resify render -o 'out/{{.Base}}.html' jane.yaml john.yaml
+//
+// The output rendered from each file has its leading and trailing whitespace trimmed, so files written one after the other
+// to the same output run together with nothing between them, and the output as a whole ends with a single newline unless
+// -newline=false is given. If -no-trim is given, each file's output is written exactly as its template produced it, so any
+// whitespace separating files must come from the template itself. The trailing newline is still written (to the end of the
+// whole output, or of each file written to a pattern) unless -newline=false is also given:
+//
+//  $ resify render -format text -no-trim -newline=false -template block.tem resume.yaml
+//
+// Each file's output is held in memory until it's rendered, so that it can be trimmed and so that a file that fails to
+// render writes nothing. For very large output, -stream instead writes output as the template produces it. Streaming
+// implies -no-trim, renders files one at a time regardless of -jobs, and can't be combined with -bundle. A file that fails
+// to render partway through leaves what was already written of it in the output, so check the exit code before using it:
+//
+//  $ resify render -stream -o 'out/{{.Base}}.html' people/
+//
+// If a file given to render cannot be read or rendered, resify stops and returns an exit code for the problem (see below).
+// If -keep-going is given, resify instead skips that file and continues with the rest, listing the files that failed and
+// returning the exit code of the first failure once it's done.
+//
+// Besides files, render accepts directories, which are replaced by the .yaml, .yml, and .toml files directly in them
+// (or beneath them, if -r is given) in order by path, and glob patterns, which resify expands itself for shells that
+// don't. Anything else is an error, as is a directory or pattern without any resume files. Together with an output
+// pattern, this renders a whole directory of resumes at once, using {{.Dir}} if files in different directories share a
+// name:
+//
+//  $ resify render -r -o 'out/{{.Dir}}/{{.Base}}.html' variants
+//  $ resify render -o 'out/{{.Base}}.html' 'variants/*.yaml'
+//
+// If -jobs is given to render, up to that many files are rendered at once. Output is still written in the order the files
+// were given.
+//
+// If -dry-run is given to render, every file is read and rendered as usual, so errors are still reported, but nothing is
+// written. Instead, the path each output would be written to, whether it would be created or overwritten, and its size are
+// logged. If output isn't written to a pattern, only the total size of the output is logged:
+//
+//  $ resify render -dry-run -o 'out/{{.Base}}.html' *.yaml
+//
+// If -check is given to render, every file is read and rendered as usual, but instead of being written, the output is
+// compared byte for byte with the file already at its output path, such as a rendered resume committed alongside its
+// source. Nothing is written. If any output file is missing or differs, resify logs which, along with the first line that
+// differs, and exits with status 6, so that CI can fail when someone forgets to render again after changing a resume or
+// its templates. With an output pattern, every output is checked, and the number that are stale is logged at the end:
+//
+//  $ resify render -check -o resume.html resume.yaml
+//
+// The output must be written to a file, not stdout. Rendering is deterministic, with metadata, profiles, and map keys
+// always in the same order, so output only changes when its inputs do, with one exception: a template using .RenderedAt
+// is never up to date.
+//
+// If -watch is given to render, resify keeps running after rendering and polls the templates directory, the files
+// given, and the files they include for changes. Once changes have settled, templates are reloaded and everything is
+// rendered again, overwriting the previous output. Each render and any errors are logged with the time they happened,
+// and resify keeps watching after errors until interrupted. Stdin cannot be watched.
+//
+// Flags may be given either before or after the command. If the -json flag is given to the render command, no templates are
+// loaded and each resume is instead written to the output as JSON (pretty-printed if -indent is also given). Dates in JSON
+// output are objects with "from" and "to" strings, same as in YAML, and metadata is placed under a "meta" key:
+//
+//  $ resify render -json -indent me.yaml | jq .
+//
+// If -export jsonresume is given to render, each resume is instead written as JSON following the JSON Resume schema
+// (https://jsonresume.org/schema/), for use with its themes and tools. Dates are written as "YYYY-MM-DD", ongoing date
+// ranges have no end date, and anything without an equivalent in the schema, such as metadata, is left out. -indent
+// applies here as well.
+//
+// If -export csv or -export tsv is given to render, one section of each resume, given by -export-section (by default
+// work), is instead written as a table of comma- or tab-separated values with a header row, such as for uploading to a
+// system that only takes spreadsheets. Work is written with the columns title, employer, location, from, to,
+// description, and highlights; education with school, location, received, fields, from, to, description, and
+// highlights; awards with title, awarder, date, and summary; publications with title, publisher, date, url, and
+// summary; and references with name, relationship, contact, and note. Dates are written as "YYYY-MM", and ongoing date
+// ranges end with "present". Fields of study are separated by semicolons, and highlights are written one per line
+// within their cell. Text is written as it is in the resume file, links included. Since tables can't be concatenated,
+// more than one file can only be exported with an output pattern:
+//
+//  $ resify render -export csv -export-section education -o '{{.Base}}-education.csv' jane.yaml john.yaml
+//
+// Dates in YAML and JSON output are written the same way they were written in the resume file. If -date-layout is given, all
+// dates are instead written using that layout, in Go's time layout format, such as "2006-01" to write only years and months.
+// Dates are parsed with one of the following layouts, and times keep their time zone. Zone abbreviations (MST) other than
+// UTC and the local time zone are kept as written but have no known offset, so times that must be exact should use numeric
+// offsets (-0700):
+//
+//  2006-01-02T15:04:05Z07:00
+//  2006-01-02 15:04:05 -0700
+//  2006-01-02 15:04 -0700
+//  2006-01-02 15:04:05 MST
+//  2006-01-02 15:04:05
+//  2006-01-02 15:04 MST
+//  2006-01-02 15:04
+//  2006-01-02
+//  2006-01
+//  2006
+//  Jan 2006
+//  January 2006
+//  1/2006
+//  2006/1
+//  1/2/2006
+//
+// Numeric dates with slashes are always read month first, so 01/02/2015 is January 2nd. Dates written with month names or
+// slashes are written back out in the ISO-style layout above with the same precision, such as "2015-08" for "Aug 2015".
+//
+// Resume files ending in ".toml" are read as TOML, using the same keys as YAML. All other files, including stdin, are read as
+// YAML. The -input-format flag (yaml or toml) overrides this for every input.
+//
+// A resume file may include other resume files with a top-level include key listing their paths. Paths are relative to
+// the directory of the including file (or the working directory for stdin) and may not leave it, even through symlinks.
+// Included files may include others, but not themselves. Their work, education, award, publication, and reference
+// entries are appended to those of the including file, and any profiles, contact details, or metadata they have are
+// used where the including file doesn't have its own. A file included more than once, such as by two included files, is
+// only merged the first time:
+//
+//  include: [work.yaml, education.yaml]
+//
+// With the -expand-env flag, ${VAR} in any string value of a resume file is replaced by the value of the environment variable
+// VAR, such as to keep contact details out of a shared resume file:
+//
+//  me:
+//    email: ${RESUME_EMAIL}
+//
+// Keys, non-string values such as numbers and dates, and bare $VAR references are left alone, as is link syntax such as
+// ((URL label)) around a reference. Unset variables expand to an empty string, or are an error if -require-env is also
+// given. Included files are expanded the same way.
+//
+// By default, keys in resume files that resify doesn't recognize are kept as metadata. If the -strict flag is given to the
+// render or validate commands, unrecognized keys are instead reported as errors. Since metadata is made of unrecognized
+// keys, this means resume files read with -strict cannot have metadata.
+//
+// resify expects to find templates under pwd/templates with the file extension ".tem" (or the extension given by the
+// -template-ext flag, with or without its leading dot). If there's no templates directory in the working directory, the
+// nearest one in a parent directory is used instead, so resify can be run from anywhere in a project. Giving -data-dir
+// disables this search. Templates may be organized into subdirectories, which are searched
+// recursively. Each template is named by its path relative to the templates directory, so templates/index.tem is
+// "index.tem" and templates/partials/header.tem is "partials/header.tem". The same template name may not be defined in more
+// than one file. The template executed is "index" with the template extension unless another is given by -template, which
+// may also omit the extension. If there's no index template and only one template file directly in the templates directory,
+// that file is executed instead; if there's more than one, -template must name one of them. If any templates fail to
+// compile or cannot be rendered, an error is written to standard error and resify returns 4.
+//
+// Templates are HTML templates unless -format gives another output format: "html" (the default), "text", or "markdown".
+// HTML output is escaped according to its context, and text output isn't escaped at all. Markdown output is text output
+// where the text given to linkify and link is escaped so that it isn't read as Markdown, links are written as
+// [label](URL), and serve writes the result as text/markdown. -text is a deprecated alias for -format text:
+//
+//  $ resify render -format markdown -template resume.md -o resume.md resume.yaml
+//
+// -data-dir may also be the path of a gzipped tarball of templates and other data, ending in ".tar.gz" or ".tgz", such as for
+// CI jobs that don't check out a templates directory. The tarball's contents are extracted to a temporary directory that's
+// used in its place and removed when resify exits. Entries with absolute paths or paths leaving the tarball through "..", and
+// symbolic or hard links, are rejected:
+//
+//  $ resify render -data-dir templates.tar.gz -o resume.html resume.yaml
+//
+// -template may also name a template defined inside another file, such as a contact block for an email signature defined
+// with {{ define "contact" }}, to render only that template:
+//
+//  resify render -format text -template contact resume.yaml
+//
+// If no template has the name given, resify lists the templates that do exist.
+//
+// The -template flag may also be the path of a template file outside of the templates directory, or "-" to read the template
+// from stdin. Paths must begin with "./", "../", or "/", or contain a slash and name an existing file; anything else is the
+// name of a loaded template. The template file is loaded alongside any templates in the templates directory, which needn't
+// have any, and functions like embed still read from the templates directory:
+//
+//  $ resify render -template ./one-off.tem me.yaml
+//
+// Templates use "{{" and "}}" to delimit actions unless other delimiters are given by -delims, as a left and right
+// delimiter separated by a space. This applies to every template, including those read by -template, but not to the
+// default link template or to output path patterns:
+//
+//  $ resify render -delims '[[ ]]' me.yaml
+//
+// A template may begin with front matter: a block of YAML fenced by "---" lines, which is removed before the template is
+// parsed. The front matter of the main template may give an output path, used as the output path (or pattern) when -o
+// isn't given, and a content type, used by the serve command in place of the default for text or HTML output:
+//
+//  ---
+//  output: out/{{.Base}}.html
+//  contentType: text/html; charset=utf-8
+//  ---
+//  <!DOCTYPE html>
 //
 // Templates have access to any data under templates/ and all data associated with the rtype.Resume data structure.
 //
@@ -21,6 +269,25 @@
 //
 //  embed: Load a file beneath the template directory and return its contents. This may need to be piped to either html,
 //      attr, or css depending on the context.
+//      Files outside of the template directory, including those reached through symlinks, cannot be loaded.
+//      Embedding a file that doesn't exist fails the render with an error naming the file and the template embedding it,
+//      unless -ignore-missing-embeds is given, in which case a warning is logged and nothing is embedded.
+//
+//  dataURI: Load a file beneath the template directory and return it as a base64 data URI, such as for images and fonts
+//      in a standalone HTML file, as in <img src="{{ dataURI "me.jpg" }}">. The MIME type is determined by the file's
+//      extension or, failing that, its contents. In HTML output, the URI is safe for use as a URL.
+//
+//  photo: Returns .Me.Photo as a URL for use in <img src>. An absolute http(s) URL is returned as it is, and a path is
+//      loaded from beneath the template directory and returned as a data URI, as dataURI does. In HTML output, the result
+//      is safe for use as a URL. In text output, the photo is returned as given, whether it's a URL or a path.
+//
+//  srcset: Returns a srcset attribute value for a responsive image from the images given, each a path beneath the template
+//      directory optionally followed by a width or pixel density descriptor, as in
+//      <img srcset="{{ srcset "me-320.jpg 320w" "me-640.jpg 640w" }}" src="me-640.jpg">. Paths are written as relative
+//      URLs, so the images must be published alongside the output, but each must exist beneath the template directory.
+//      In HTML output, the value is safe for use in a srcset attribute.
+//
+//  dataSrcset: The same as srcset, but inlines each image as a data URI, as dataURI does, for a standalone HTML file.
 //
 //  html: In HTML output, declare that the string passed to html is safe for the HTML context.
 //
@@ -30,9 +297,115 @@
 //
 //  js: In HTML output, declare that the string passed is safe for the Javascript context.
 //
+//  url: In HTML output, declare that the string passed is safe for the URL context, such as the value of an href or src
+//      attribute. Use url for a URL inside an attribute's value, as in <a href="{{ url .Meta.site }}">, and attr only for
+//      whole attributes or attribute names, as in <input {{ attr "disabled" }}>. URLs that aren't marked safe are still
+//      escaped, and any with a scheme other than http, https, or mailto are replaced with "#ZgotmplZ", so only use url for
+//      URLs you trust, such as data: URIs.
+//
+//  escape: In Markdown output, escapes the Markdown punctuation (\, `, *, _, [, ], <, and >) in the string passed, so that
+//      it's written as literal text instead of formatting, as in {{ escape .Title }}. linkify and link do this for the
+//      text they're given. In HTML and text output, the string is returned as is, since HTML output is already escaped.
+//
 //  linkify: Returns the string given to it with all instances of ((URL label)) with whatever the result of using the "link"
-//      template to render them is. If no "link" (not "link.tem") template is defined, the result is the label string.
-//      If there is no label string, the result is some form of the URL.
+//      template to render them is. If no "link" (not "link.tem") template is defined, a default one is used that renders
+//      <a href="URL">label</a> in HTML output, "label (URL)" in text output, and [label](URL) in Markdown output. If there is
+//      no label string, the label is some form of the URL.
+//      Markdown-style links of the form [label](URL) are handled the same as ((URL label)).
+//      Bare http(s) URLs and email addresses are also linked, with the URL or address as the label, unless -autolink=false
+//      is given.
+//      If -no-linkify is given, linkify converts nothing and only escapes the string in HTML and Markdown output. -link-pattern
+//      replaces the regular expression that finds links with a stricter one. Text it matches must still be a link of
+//      either form, so -link-pattern '\(\(.+?\)\)' only converts ((URL label)) links and leaves Markdown-style links as
+//      they are.
+//
+//  link: Renders a link to the URL given with the "link" template, the same as linkify does, as in {{ link .URL .Title }}.
+//      The label is optional and is some form of the URL if omitted. If the URL is empty, the label is returned alone.
+//
+//  markdown: Renders the string given to it as Markdown. In HTML output, the result is HTML. In text output, formatting
+//      is stripped and the result is plain text. In Markdown output, the string is already Markdown and is returned as
+//      is. markdown may follow linkify in a pipeline, as in {{ .Description | linkify | markdown }}, to render both
+//      links and Markdown.
+//
+//  date: Formats a time (such as .When.From) using the layout given, as in {{ date "Jan 2006" .When.From }}. Zero times are
+//      formatted as an empty string. Given a date range (such as .When), its non-empty ends are formatted and joined by
+//      " - ". Date ranges also have a Format method that does the same, as in {{ .When.Format "Jan 2006" }}.
+//
+//  dateLoc: Formats a time or date range like date, but with month and weekday names in the locale given by -locale, as
+//      in {{ dateLoc "January 2006" .When.From }}, which gives "Januar 2015" with -locale de. The locales supported are
+//      en (the default), de, fr, and es; only the language of a locale like de-AT is used, and dates in unknown locales are
+//      written in English with a warning. Only names are localized, so layouts should still be written in the order the
+//      locale expects, as in "2. January 2006" for German. Resume files are always read and written with English
+//      names regardless of -locale.
+//
+//  year: Returns the four-digit year of a time or date range. This is the same as date with the layout "2006".
+//
+//  sortByDate: Sorts a list of entries with date ranges, such as .Employment or .Awards, by when they start. It takes the
+//      list and a direction, either "asc" (oldest first) or "desc" (newest first), as in
+//      {{ range sortByDate .Employment "desc" }}.
+//
+//  filterMeta: Filters a list of entries with metadata, such as .Employment, to those where the given metadata key is true.
+//      If followed by false, it instead keeps those where the key is false or missing, as in
+//      {{ range filterMeta .Employment "hidden" false }}.
+//
+//  metaStr, metaBool, metaInt: Return the value of a metadata key as a string, bool, or int, as in
+//      {{ metaStr .Meta "manager" "N/A" }}. If the key is missing or its value can't be converted, the optional default
+//      given after the key is returned, or the zero value if there's no default. metaStr formats values of any type, metaBool
+//      also accepts strings like "true" and "false", and metaInt accepts whole numbers and strings of integers. These also
+//      read the values given by -set, as in {{ metaInt .Extra "year" }}.
+//
+//  phone: Formats a phone number, such as .Me.Phone, for reading. North American numbers like +12345678901 are formatted as
+//      +1 (234) 567-8901. Other numbers, and numbers that can't be parsed, are unchanged.
+//
+//  telURI: Returns a phone number as a tel: URI for use in links, as in <a href="{{ telURI .Me.Phone }}">. In HTML output,
+//      the URI is safe for use as a URL. Numbers that can't be parsed are unchanged (and not marked safe).
+//
+//  obfuscate: In HTML output, encodes every character of the string given to it (such as .Me.Email) as an HTML character
+//      reference, so that it reads and copies normally in a browser but is harder to scrape. In text output, the string
+//      is returned unchanged.
+//
+//  mailto: In HTML output, returns a mailto: link to the email address given, with the address obfuscated the same as by
+//      obfuscate in both the link and its text, as in {{ mailto .Me.Email }}. In text output, the address is returned
+//      unchanged.
+//
+//  words: Returns the number of words in a string, as separated by any whitespace. Links are counted by their labels, so
+//      this should be given text before it's passed to linkify, as in {{ if gt (words .Description) 60 }}.
+//
+//  readingTime: Returns the estimated number of minutes needed to read a string at 200 words per minute, rounded up. Like
+//      words, links are counted by their labels.
+//
+//  totalExperience: Returns the combined length of a list of employment entries in years and months, as in
+//      {{ totalExperience .Employment }} of experience, which renders as "6 years, 2 months of experience". Time spent in
+//      overlapping jobs is only counted once, and ongoing jobs are counted up to now. The length of a single entry is
+//      given by .When.Duration, and either has .Years and .Months fields for other formatting.
+//
+//  groupByEmployer: Groups a list of employment entries by their employer's name (.Where.Name), for several roles at one
+//      company nested under one heading. Each group has the employer's .Employer place, taken from its first role, and its
+//      .Roles in the order given. Groups are in the order each employer first appears, and roles at the same employer are
+//      grouped even if other employers come between them. Entries without an employer name each get a group of their own:
+//
+//      {{ range groupByEmployer .Employment }}<h3>{{ .Employer.Name }}</h3>
+//        {{ range .Roles }}<h4>{{ .Title }}</h4>{{ end }}
+//      {{ end }}
+//
+//  sections: Lists the sections of a resume that have entries, for a table of contents that only links to sections that
+//      are there. It's given the resume, usually as the template's dot, and returns the work, education, awards,
+//      publications, and references sections that aren't empty, in that order. Each has its .Key in resume files, such
+//      as "work", a .Title, such as "Employment", and a .Count of its entries:
+//
+//      <nav>{{ range sections . }}<a href="#{{ slug .Title }}">{{ .Title }} ({{ .Count }})</a>{{ end }}</nav>
+//
+//  contactLinks: Lists the ways of contacting a resume's owner as links, given the resume, usually as the template's dot.
+//      The links are, in order, a mailto: link to .Me.Email, a tel: link to .Me.Phone (see telURI), and a link to each
+//      profile in the order given by its .order key. An empty email or phone number, a phone number telURI can't use, and
+//      a profile without a URL are skipped. Each link has the .URL and .Label the "link" template expects, along with a
+//      .Key of "email", "phone", or the profile's key:
+//
+//      <p>{{ range $i, $l := contactLinks . }}{{ if $i }} | {{ end }}{{ template "link" $l }}{{ end }}</p>
+//
+//  slug: Returns a string, such as a heading, as an anchor for use in element IDs and links, as in
+//      <h3 id="{{ slug .Title }}"> and <a href="#{{ slug .Title }}">. Letters are lowercased, spaces become hyphens, and
+//      anything other than letters, digits, and hyphens is removed.
 //
 // An example template for use with resify (as templates/index.tem):
 //
@@ -44,188 +417,420 @@
 //  </head>
 //  <body>
 //      <h1>{{ .Me.Chosen }}</h1>
-//      {{ if .Meta.statement }}<p>{{ .Meta.statement }}</p>{{ end }}
+//      {{ with or .Summary .Meta.statement }}<p>{{ linkify . }}</p>{{ end }}
 //
 //      <h2>Employment</h2>
 //      <ul>{{ range $e := .Employment }}
 //          <li>
 //              <h3>{{ .Title }}</h3>
-//              <p>{{ .Where.Name }} ({{ .Where.Place }})</p>
+//              <p>{{ .Where.Name }} ({{ .Where.Line }})</p>
 //              <p>{{ .Description | linkify }}</p>
+//              {{ if .Highlights }}
+//              <ul>{{ range .Highlights }}<li>{{ linkify . }}</li>{{ end }}</ul>
+//              {{ end }}
 //          </li>
 //      {{ end }}</ul>
 //
 //      <h2>Education</h2>
 //      <ul>{{ range $e := .Education }}
 //          <li>
-//              <h3>{{ .Where.Name }} ({{ .Where.Place }})</h3>
-//              <p>{{ .Where.Place }}</p>
+//              <h3>{{ .Where.Name }} ({{ .Where.Line }})</h3>
+//              <p>{{ .Where.Line }}</p>
 //              <p><em>{{ or .Received "No degree" }}.</em></p>
 //              {{ if .Fields }}
 //              <p>Studied {{ range $nth, $f := .Fields }}{{ if gt $nth 0 }}, {{ end }}<em>{{ . }}</em>{{ end }}</p>
 //              {{ end }}
 //              <p>{{ .Description | linkify }}</p>
+//              {{ if .Highlights }}
+//              <ul>{{ range .Highlights }}<li>{{ linkify . }}</li>{{ end }}</ul>
+//              {{ end }}
 //          </li>
 //      {{ end }}</ul>
 //  </body>
 //  </html>
 //
-// The only noteworthy part of the above template is the Meta.statement block -- most, but not all, data in the YAML file
-// given can also have associated metadata that may be used to populate fields that may be specialized/esoteric (e.g., your
-// manager's name, a note about some unusual thing, etc.).
+// Work and education entries may have a freeform desc, a list of highlights, or both. Highlights are discrete points, each
+// a string that can be given to linkify, and are better suited to bulleted lists than a description:
+//
+//  work:
+//    - title: Software Engineer
+//      desc: Backend work on the billing system.
+//      highlights:
+//        - Cut invoice generation time by 80%.
+//        - Wrote ((https://example.com/postmortem the postmortem)) for the outage of 2015.
+//
+// .Where.Line assembles a single-line address from the structured city, region, postal, and country fields of a place,
+// falling back to its freeform place field if it has none of those.
+//
+// Awards are listed under the awards key, each with a title, awarder, date, and summary. Like any date range, an award's date
+// may be a mapping with from and to keys, but may also be a single date, as in "date: 2014-05".
+//
+// Publications are listed under the publications key, each with a title, publisher, date, URL, and summary. Like an award's,
+// a publication's date is usually a single date. Publications can be listed newest first with sortByDate and linked with
+// the link function:
+//
+//  {{ range sortByDate .Publications "desc" }}<li>{{ link .URL .Title }}, {{ .Publisher }} ({{ year .Date }})</li>{{ end }}
+//
+// References are listed under the references key, each with a name, relationship, contact, and note. Since references
+// usually only belong in some outputs, any section can be left out with the -omit flag, which names a section by its key
+// (me, profiles, work, education, awards, publications, or references). -omit may be given more than once, and each use
+// may name several sections separated by commas, so a short version of a resume might be rendered with:
+//
+//  resify render -omit references -omit education,awards -o short.html resume.yaml
+//
+// An unknown section name is an error.
+//
+// Omitted sections are cleared after a resume and its includes are read and normalized, before it's validated, exported,
+// or rendered, so no template can see them. -omit takes precedence over anything a template does: a template that renders
+// .References renders nothing for them when references are omitted, and a template that doesn't render them needs no -omit.
+//
+// Entries in the work, education, awards, publications, and references sections may be tagged, so that resumes tailored
+// to different applications can be rendered from one file:
+//
+//  work:
+//    - title: Software Engineer
+//      tags: [backend, go]
+//    - title: Team Lead
+//      tags: [leadership]
+//
+// -tag and -exclude-tag select entries by tag, and may be given more than once or list several tags separated by commas.
+// Tags are compared without regard to case. An entry is left out if it has any tag given by -exclude-tag, regardless of its
+// other tags. Otherwise, an entry with tags is kept if it has any tag given by -tag, or if -tag isn't given, and an entry
+// without tags is kept unless -tagged-only is given. So, to render only backend work and anything untagged, without
+// entries about leadership:
+//
+//  $ resify render -tag backend,go -exclude-tag leadership -o backend.html resume.yaml
+//
+// Entries are filtered along with -omit, so exports, validation, and templates only see the entries kept. Templates can
+// also read an entry's tags as .Tags.
+//
+// Values that aren't resume data, such as the date a resume was generated or the company a cover page is written for, can be
+// given to templates with -set key=value, which may be repeated. Templates read them as .Extra alongside the resume's own
+// fields, as in {{ .Extra.company }}. Every value in .Extra is a string, so use metaBool and metaInt to read flags and
+// numbers, and metaStr for keys that may not be set, since a missing key renders as "<no value>" in text output:
+//
+//  $ resify render -set company=Foobiz -set draft=true -o cover.html resume.yaml
+//  {{ if metaBool .Extra "draft" }}DRAFT{{ end }} Dear {{ metaStr .Extra "company" "hiring manager" }},
+//
+// If -bundle is given to render with HTML output, the stylesheets, scripts, and images referred to by the output are inlined
+// into it, for a single file that can be emailed or opened anywhere. After rendering, each <link rel="stylesheet" href=...>
+// becomes a <style> element holding the stylesheet, each <script src=...></script> holds its script, and each
+// <img src=...> uses a data URI, as dataURI does. Files referred to by url() in inlined stylesheets, such as fonts and
+// background images, are also inlined as data URIs, relative to the stylesheet. Only paths beneath the templates directory
+// are inlined, with the same restrictions as embed, and absolute URLs, such as those of web fonts, are left alone. srcset
+// attributes are unchanged, and so are tags in HTML comments and in the text of scripts and stylesheets, so use dataSrcset
+// for srcset. -bundle only runs on HTML output, and it's an error to give it with -format text or markdown, or with -json:
+//
+//  $ resify render -bundle -o resume.html resume.yaml
+//
+// Templates also have .SourceModTime, when the resume file given was last modified, and .RenderedAt, when it was rendered,
+// as times for use with date. Files included by the resume aren't considered, and a resume read from stdin has the same
+// .SourceModTime as .RenderedAt:
+//
+//  <footer>Data updated {{ date "2006-01" .SourceModTime }}, rendered {{ date "2006-01" .RenderedAt }}</footer>
+//
+// .Me.Name assembles a name from the fields and metadata of .Me named by its "ordered" key, in order, falling back to
+// .Me.Chosen.
+// .Me.OrderedFields resolves the same keys to name and value pairs instead, skipping those that name nothing or an empty
+// value, so a contact block can be ordered by the resume rather than the template:
+//
+//  {{ range .Me.OrderedFields }}{{ .Name }}: {{ .Value }}{{ end }}
+//
+// .Me.Photo, given by the photo key, is a photo of you in one of two forms: an absolute http(s) URL, such as
+// "https://example.com/me.jpg", or the path of an image file beneath the templates directory, such as "me.jpg". The photo
+// function turns either into a URL for an img element, and .Me.PhotoIsURL tells them apart:
+//
+//  {{ with .Me.Photo }}<img src="{{ photo . }}" alt="">{{ end }}
+//
+// Profiles can be listed in the order given by their ".order" key using .Profiles.Ordered, which yields each profile along
+// with its key. Profiles not named by ".order" come last, sorted by key:
+//
+//  {{ range .Profiles.Ordered }}<a href="{{ .URL }}">{{ or .Label .Key }}</a>{{ end }}
+//
+// Profile URLs without a scheme that begin with a domain name, such as "github.com/me", are read as https URLs.
+//
+// The .Summary in the above template is an optional statement or objective introducing the resume, given by the summary
+// key at the top of a resume file. Like descriptions, it can be rendered with linkify or markdown. Older resume files may
+// keep it in the statement metadata key instead, which the above template falls back to; -normalize copies it into
+// .Summary if the summary is empty.
+//
+// Most, but not all, data in the YAML file given can also have associated metadata that may be used to populate fields
+// that may be specialized/esoteric (e.g., your manager's name, a note about some unusual thing, etc.).
+//
+// Rendering is also available to Go programs as the package github.com/nilium/resify/render, which loads templates and
+// embedded files from any fs.FS, such as templates compiled into a program with go:embed. The links understood by linkify
+// can be parsed and rendered with the package github.com/nilium/resify/linkify.
 //
 // This is obviously not the be-all-end-all of tools for separating resume data and rendition, it's just good enough for my
 // purposes, which is mainly so I don't have to update three different formats all the time. At most, I need to update the
 // data, and then any change in format can be handled by a template.
 //
-// Some massaging of the data that I intended to have but didn't do because it didn't really make sense, like filling in empty
-// fields based on others if you didn't provide one, are on the TODO list and probably won't actually happen unless there's
-// some reason I need them.
+// Some massaging of the data is available with the -normalize flag, which fills in empty fields based on others after a
+// resume is read: .Me.Order defaults to [chosen], .Summary defaults to the statement metadata key, profile labels
+// default to the host of their URL, and the freeform place of employment and education entries defaults to .Where.Line.
+// Fields that have values are left alone.
 package main // import "github.com/nilium/resify"
 
 import (
-	"bytes"
-	"crypto/sha1"
-	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"flag"
+	"fmt"
 	"io"
+	"io/fs"
 	"io/ioutil"
 	"log"
-	"net/url"
+	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/nilium/resify/render"
 	"github.com/nilium/resify/rtype"
 
-	htmlt "html/template"
-	textt "text/template"
-
-	blackfriday "gopkg.in/russross/blackfriday.v2"
+	"github.com/BurntSushi/toml"
 	yaml "gopkg.in/yaml.v2"
 )
 
 const whitespace = "\r\n\t "
 
-var (
-	errNotALink      = errors.New("not a link")
-	errEscapeAttempt = errors.New("attempt to leave data directory via embed")
-)
+var errIncludeEscape = errors.New("attempt to leave resume directory via include")
 
 var dataDir = filepath.Join("templates/")
 
-type template interface {
-	ExecuteTemplate(io.Writer, string, interface{}) error
-}
-
-var formatter template
+// dataDirFS is the filesystem of the directory at its path. Unlike os.DirFS, files that are outside of the directory
+// through symlinks cannot be opened, and render.ErrEscapeAttempt is returned instead.
+type dataDirFS string
 
-var linkFormat = regexp.MustCompile(`\(\(.+?\)\)`)
+func (d dataDirFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
 
-type Link struct {
-	URL   *url.URL
-	Label string
+	path, err := resolveDataPath(string(d), filepath.FromSlash(name))
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
 }
 
-func parseLink(src string) (link Link, err error) {
-	if !strings.HasPrefix(src, "((") || !strings.HasSuffix(src, "))") || len(src) <= 4 {
-		return Link{}, errNotALink
+// resolveDataPath returns the absolute path, with all symlinks resolved, of the file at path relative to dir. If the
+// resolved path is not beneath dir (itself with symlinks resolved), render.ErrEscapeAttempt is returned.
+func resolveDataPath(dir, path string) (string, error) {
+	path = filepath.Clean(path)
+	if escapesDir(filepath.ToSlash(path)) {
+		return "", render.ErrEscapeAttempt
 	}
 
-	src = strings.Trim(src[2:len(src)-2], whitespace)
-	if len(src) == 0 {
-		return Link{}, errNotALink
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		return "", err
 	}
 
-	components := strings.SplitN(src, " ", 2)
-	link.URL, err = url.Parse(strings.Trim(components[0], whitespace))
+	resolved, err := filepath.EvalSymlinks(filepath.Join(root, path))
 	if err != nil {
-		log.Printf("error parsing link %q: %v", components[0], err)
-		return Link{}, err
+		return "", err
 	}
 
-	if len(components) > 1 {
-		link.Label = strings.Trim(components[1], whitespace)
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || escapesDir(filepath.ToSlash(rel)) {
+		return "", render.ErrEscapeAttempt
 	}
+	return resolved, nil
+}
 
-	if len(link.Label) == 0 {
-		link.Label = link.URL.Host + link.URL.Path
+// escapesDir returns whether the cleaned, relative path refers to something outside of the directory it's relative to.
+func escapesDir(path string) bool {
+	return path == ".." || strings.HasPrefix(path, "../")
+}
+
+const (
+	formatYAML = "yaml"
+	formatTOML = "toml"
+)
+
+// inputFormat returns the format of the resume file at path. If format is non-empty, it overrides the format implied by
+// the path's extension. Files ending in ".toml" are TOML and everything else, including stdin, is YAML.
+func inputFormat(path, format string) (string, error) {
+	switch format {
+	case formatYAML, formatTOML:
+		return format, nil
+	case "":
+	default:
+		return "", fmt.Errorf("unrecognized input format: %q", format)
+	}
 
-		if len(link.Label) == 0 {
-			link.Label = link.URL.String()
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		return formatTOML, nil
+	}
+	return formatYAML, nil
+}
+
+// readOptions controls how resume files are read.
+type readOptions struct {
+	Format     string          // Input format: yaml, toml, or empty to pick one by file extension.
+	Strict     bool            // Whether unknown keys are an error instead of metadata.
+	Normalize  bool            // Whether to fill derived fields (see rtype.Resume.Normalize) after reading.
+	Omit       []string        // Sections to clear (see rtype.Resume.Omit) after reading.
+	Tags       rtype.TagFilter // Tags selecting list entries to keep (see rtype.Resume.FilterTags) after reading.
+	ExpandEnv  bool            // Whether to expand ${VAR} references to environment variables in string values.
+	RequireEnv bool            // Whether a reference to an unset environment variable is an error when expanding them.
+
+	// KeepBadDates is whether dates that cannot be parsed are left zero, with their errors kept for validateResume to
+	// report, instead of failing the read.
+	KeepBadDates bool
+
+	// Included, if not nil, is called with the path of each file a resume includes, even if it can't be read, so that it
+	// can be watched for changes. It may be called concurrently when several resumes are read at once.
+	Included func(path string)
+}
+
+// parseOmit parses the value of -omit, a comma-separated list of sections to omit from resumes. Every section must be known
+// to rtype.Resume.Omit.
+func parseOmit(s string) ([]string, error) {
+	var sections []string
+	for _, section := range strings.Split(s, ",") {
+		if section = strings.TrimSpace(section); section == "" {
+			continue
+		}
+		if err := new(rtype.Resume).Omit(section); err != nil {
+			return nil, fmt.Errorf("cannot omit section: %w", err)
 		}
+		sections = append(sections, section)
 	}
+	return sections, nil
+}
 
-	return link, err
+// sectionList is the value of the -omit flag. Each use of the flag adds the sections it names to the list.
+type sectionList []string
+
+func (l *sectionList) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
 }
 
-// renderLink renders a link of the form ((URL label)) using the program's "link" template (it must be defined in one of the
-// loaded template files). If a link cannot be rendered, the label text alone is returned. If the link cannot be parsed at all,
-// the original string is returned.
-//
-// If there is no label, the URL's hostname (sans port) and path is used as the label. If the URL has no hostname nor path,
-// besides that being weird, the full URL will be used.
-func renderLink(p string, t template) (string, error) {
-	link, err := parseLink(p)
+func (l *sectionList) Set(s string) error {
+	sections, err := parseOmit(s)
 	if err != nil {
-		return p, err
+		return err
 	}
+	*l = append(*l, sections...)
+	return nil
+}
 
-	var buf bytes.Buffer
-	if err := t.ExecuteTemplate(&buf, "link", link); err != nil {
-		log.Println("error rendering link:", err)
-		return link.Label, err
-	} else {
-		return buf.String(), nil
+// tagList is the value of the -tag and -exclude-tag flags. Each use of a flag adds the tags it names, separated by commas,
+// to the list.
+type tagList []string
+
+func (l *tagList) String() string {
+	if l == nil {
+		return ""
 	}
+	return strings.Join(*l, ",")
 }
 
-// linkify converts any links of the format ((URL label)) to links in the template by passing them all through the template's
-// "link" template and returning the result. Non-link text is escaped and returned before re-inserting rendered links back into
-// the text. Escaping only affects HTML output.
-func linkify(s string) string {
-	repls := map[string]string{}
-	s = linkFormat.ReplaceAllStringFunc(s, func(p string) string {
-		sum := sha1.Sum([]byte(p))
-		id := "$" + hex.EncodeToString(sum[:]) + "$"
-		if _, ok := repls[id]; ok {
-			return id
+func (l *tagList) Set(s string) error {
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			*l = append(*l, tag)
 		}
+	}
+	return nil
+}
 
-		l, err := renderLink(p, formatter)
-		if err != nil {
-			return l
-		}
-		repls[id] = l
+// sourceModTime returns the modification time of the resume file at path, or the zero time if it's read from stdin or its
+// modification time can't be read.
+func sourceModTime(path string) time.Time {
+	if path == "-" || path == "" {
+		return time.Time{}
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		debugf("cannot get modification time of %s: %v", path, err)
+		return time.Time{}
+	}
+	return fi.ModTime()
+}
 
-		return id
-	})
+// extraValues is the value of the -set flag, given to templates as .Extra (see render.Options). Each use of the flag sets one
+// key to a value, given as key=value, replacing any value already set for the key.
+type extraValues map[string]string
 
-	s = escape(s)
-	for id, link := range repls {
-		s = strings.Replace(s, id, link, -1)
+func (m extraValues) String() string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
 	}
+	sort.Strings(keys)
 
-	return s
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + m[k]
+	}
+	return strings.Join(pairs, ",")
 }
 
-// readFile opens the file at path and returns its contents as a string. If any error occurs, that error is returned with an
-// empty string.
-func readFile(path string) (string, error) {
-	path = filepath.Clean(path)
-	if path == ".." || strings.HasPrefix(path, "../") {
-		return "", errEscapeAttempt
+func (m extraValues) Set(s string) error {
+	i := strings.IndexByte(s, '=')
+	if i <= 0 {
+		return fmt.Errorf("%q must be of the form key=value", s)
 	}
-	b, err := ioutil.ReadFile(filepath.Join(dataDir, path))
-	if err != nil {
-		return "", err
+	m[s[:i]] = s[i+1:]
+	return nil
+}
+
+// extra holds the values given by -set.
+var extra = extraValues{}
+
+// readResumeFromFile reads the resume at path, or stdin if path is "-" or empty, along with any resumes it includes. Errors
+// are logged before being returned.
+func readResumeFromFile(path string, opts readOptions) (resume rtype.Resume, err error) {
+	if resume, err = decodeResumeFile(path, opts); err != nil {
+		return resume, err
 	}
-	return string(b), nil
+
+	var stack []string
+	if path != "-" && path != "" {
+		abs, err := filepath.Abs(path)
+		if err == nil {
+			abs, err = filepath.EvalSymlinks(abs)
+		}
+		if err != nil {
+			log.Printf("cannot read %s: %v", path, err)
+			return rtype.Resume{}, err
+		}
+		stack = append(stack, abs)
+	}
+
+	if err = includeResumes(&resume, path, opts, stack, map[string]bool{}); err != nil {
+		return rtype.Resume{}, err
+	}
+
+	if opts.Normalize {
+		resume.Normalize()
+	}
+
+	for _, section := range opts.Omit {
+		if err = resume.Omit(section); err != nil {
+			log.Printf("cannot read %s: %v", path, err)
+			return rtype.Resume{}, err
+		}
+	}
+	resume.FilterTags(opts.Tags)
+	return resume, nil
 }
 
-func readResumeFromFile(path string) (resume rtype.Resume, err error) {
+// decodeResumeFile reads and decodes the resume at path without processing its includes.
+func decodeResumeFile(path string, opts readOptions) (resume rtype.Resume, err error) {
 	var b []byte
 	name := path
 	if path == "-" || path == "" {
@@ -240,28 +845,125 @@ func readResumeFromFile(path string) (resume rtype.Resume, err error) {
 		return
 	}
 
-	if err = yaml.Unmarshal(b, &resume); err != nil {
-		log.Println("cannot parse", name, "as YAML:", err)
-		resume = rtype.Resume{}
+	format, err := inputFormat(path, opts.Format)
+	if err != nil {
+		log.Printf("cannot read %s: %v", name, err)
+		return
+	}
+
+	if format == formatTOML {
+		if b, err = tomlToYAML(b); err != nil {
+			log.Println("cannot parse", name, "as TOML:", err)
+			return rtype.Resume{}, err
+		}
+	}
+
+	if opts.Strict {
+		err = rtype.UnmarshalStrict(b, &resume)
+	} else {
+		err = yaml.Unmarshal(b, &resume)
+	}
+
+	if err != nil && opts.KeepBadDates {
+		err = withoutDateErrors(err, resume)
+	}
+	if err != nil {
+		log.Println("cannot parse", name, "as", strings.ToUpper(format)+":", err)
+		return rtype.Resume{}, err
+	}
+
+	if opts.ExpandEnv {
+		if err = expandEnv(&resume, opts.RequireEnv); err != nil {
+			log.Println("cannot expand environment variables in", name+":", err)
+			return rtype.Resume{}, err
+		}
+	}
+
+	return resume, nil
+}
+
+// tomlToYAML decodes a TOML document and re-encodes it as YAML. TOML input is converted instead of being decoded directly
+// into an rtype.Resume so that inline metadata, profiles, and date ranges are handled the same as they are for YAML.
+func tomlToYAML(b []byte) ([]byte, error) {
+	var doc map[string]interface{}
+	if _, err := toml.Decode(string(b), &doc); err != nil {
+		return nil, err
 	}
+	return yaml.Marshal(tomlValue(doc))
+}
 
-	return resume, err
+// tomlValue replaces any TOML datetimes in v with strings that rtype's date layouts can parse.
+func tomlValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case time.Time:
+		if v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 && v.Nanosecond() == 0 {
+			return v.Format("2006-01-02")
+		}
+		return v.Format("2006-01-02 15:04:05 -0700")
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = tomlValue(e)
+		}
+	case []map[string]interface{}:
+		for _, e := range v {
+			tomlValue(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = tomlValue(e)
+		}
+	}
+	return v
 }
 
-func generateYAML(w io.Writer) error {
-	date, err := rtype.NewDateRange("2010-08", "2015-12")
+func generateYAML(w io.Writer, minimal bool) error {
+	example := exampleResume
+	if minimal {
+		example = minimalResume
+	}
+
+	resume, err := example()
 	if err != nil {
 		return err
 	}
 
-	resume := rtype.Resume{
+	b, err := yaml.Marshal(resume)
+	if err != nil {
+		return err
+	}
+
+	return writeAll(w, b)
+}
+
+// exampleResume returns the full example resume written by the yaml and init commands, using every section.
+func exampleResume() (rtype.Resume, error) {
+	date, err := rtype.NewDateRange("2010-08", "2015-12")
+	if err != nil {
+		return rtype.Resume{}, err
+	}
+
+	awardDate, err := rtype.NewDateRange("2014-05", "")
+	if err != nil {
+		return rtype.Resume{}, err
+	}
+
+	pubDate, err := rtype.NewDateRange("2016-03", "")
+	if err != nil {
+		return rtype.Resume{}, err
+	}
+
+	return rtype.Resume{
 		Me: rtype.Me{
 			Order:  []string{"Chosen", "Ordered", "Name"},
 			Chosen: "Chosen Name",
 			Phone:  "+12345678901",
 			Email:  "you@hostname.tld",
+			Photo:  "https://hostname.tld/photo.jpg",
 		},
 
+		Summary: "Engineer who builds distributed, high-throughput servers and writes about them at " +
+			"((https://hostname.tld/blog the blog)).",
+
 		Profiles: rtype.Profiles{
 			Order: []string{"github", "twitter"},
 			Profile: map[string]rtype.Profile{
@@ -289,6 +991,10 @@ func generateYAML(w io.Writer) error {
 					`my liver with centipedes and upon my ribs inscribe ` +
 					`the word "death". I also built distributed, high-throughput ` +
 					`servers that accepted approx. 5 billion requests per day.`,
+				Highlights: []string{
+					"Built servers accepting approx. 5 billion requests per day.",
+					"Wrote ((https://example.com/postmortem the postmortem)) for the centipede incident.",
+				},
 
 				Meta: map[string]interface{}{
 					"manager": "Damien V. Satansteeth",
@@ -300,39 +1006,199 @@ func generateYAML(w io.Writer) error {
 			{
 				When: date,
 				Where: rtype.Place{
-					Name:  "Some Fake University State",
-					Place: "Deadtown, AL",
+					Name:    "Some Fake University State",
+					City:    "Deadtown",
+					Region:  "AL",
+					Country: "US",
 				},
 				Received:    "Degrees in History and Electrical Engineering", // I couldn't find a way to make this not dry.
 				Fields:      []string{"History", "Electrical Engineering"},
 				Description: "A description of acheivements at this institution like maybe you won an award who knows.",
 			},
 		},
-	}
 
-	b, err := yaml.Marshal(resume)
+		Awards: []rtype.Award{
+			{
+				Title:   "Employee of the Month",
+				Awarder: "Foobiz Studios",
+				Date:    awardDate,
+				Summary: "Awarded for not fleeing Alabama. See ((https://example.com/award the announcement)).",
+			},
+		},
+
+		Publications: []rtype.Publication{
+			{
+				Title:     "On the Throughput of Servers in Alabama",
+				Publisher: "Journal of Regrettable Locations",
+				Date:      pubDate,
+				URL:       "https://example.com/papers/throughput",
+				Summary:   "Measured how many requests per day a server can accept before it, too, wants to leave.",
+			},
+		},
+
+		References: []rtype.Reference{
+			{
+				Name:         "Damien V. Satansteeth",
+				Relationship: "Manager at Foobiz Studios",
+				Contact:      "damien@example.com",
+				Note:         "Will confirm that I did not flee Alabama.",
+			},
+		},
+	}, nil
+}
+
+// minimalResume returns a bare resume written by the yaml command with -minimal, with one entry in each section holding
+// placeholder values to be replaced.
+func minimalResume() (rtype.Resume, error) {
+	date, err := rtype.NewDateRange("2000-01", "")
 	if err != nil {
-		return err
+		return rtype.Resume{}, err
 	}
 
-	for len(b) > 0 {
-		n, err := w.Write(b)
-		if err != nil && err != io.ErrShortWrite {
-			return err
-		}
-		b = b[n:]
-	}
+	return rtype.Resume{
+		Me: rtype.Me{
+			Chosen: "Name",
+			Email:  "name@example.com",
+		},
 
-	return nil
+		Summary: "Summary",
+
+		Profiles: rtype.Profiles{
+			Order: []string{"website"},
+			Profile: map[string]rtype.Profile{
+				"website": {
+					URL:   "https://example.com",
+					Label: "Website",
+				},
+			},
+		},
+
+		Employment: []rtype.Employment{
+			{
+				Title:       "Title",
+				When:        date,
+				Where:       rtype.Place{Name: "Employer"},
+				Description: "Description",
+			},
+		},
+
+		Education: []rtype.Education{
+			{
+				When:     date,
+				Where:    rtype.Place{Name: "School"},
+				Received: "Degree",
+			},
+		},
+
+		Awards: []rtype.Award{
+			{
+				Title:   "Award",
+				Awarder: "Awarder",
+				Date:    date,
+			},
+		},
+
+		Publications: []rtype.Publication{
+			{
+				Title:     "Publication",
+				Publisher: "Publisher",
+				Date:      date,
+			},
+		},
+
+		References: []rtype.Reference{
+			{
+				Name:    "Reference",
+				Contact: "reference@example.com",
+			},
+		},
+	}, nil
 }
 
-func nopstring(s string) string { return s }
+// marshalJSON returns the resume as JSON. If indent is true, the JSON is pretty-printed.
+func marshalJSON(resume rtype.Resume, indent bool) ([]byte, error) {
+	if indent {
+		return json.MarshalIndent(resume, "", "  ")
+	}
+	return json.Marshal(resume)
+}
+
+// autolink controls whether linkify also links bare URLs and email addresses.
+var autolink = true
+
+// noLinkify and linkPattern control whether linkify converts links and, if it does, the pattern matching them (see
+// render.Options).
+var (
+	noLinkify   bool
+	linkPattern *regexp.Regexp
+)
+
+// Output formats given by -format.
+const (
+	outputHTML     = "html"
+	outputText     = "text"
+	outputMarkdown = "markdown"
+)
+
+// markdownOutput controls whether text templates produce Markdown (see render.Options).
+var markdownOutput bool
+
+// locale is the locale of month and weekday names written by dateLoc (see render.Options).
+var locale string
+
+// ignoreMissingEmbeds controls whether embedding a file that doesn't exist only logs a warning (see render.Options).
+var ignoreMissingEmbeds bool
+
+// newRenderer loads the templates beneath dataDir with the extension ext as text or HTML templates. The main template
+// is resolved from name as described by render.Options. If name is a path (see isTemplatePath), the main template is
+// instead read from that file and dataDir need not have any templates. Errors are logged before being returned.
+func newRenderer(useText bool, ext, name string) (*render.Renderer, error) {
+	opts := render.Options{
+		Text:        useText,
+		Markdown:    markdownOutput,
+		Ext:         ext,
+		Template:    name,
+		Delims:      delims,
+		Autolink:    autolink,
+		NoLinkify:   noLinkify,
+		LinkPattern: linkPattern,
+		Extra:       extra,
+		Warnf:       warnf,
+		Debugf:      debugf,
+
+		Locale:              locale,
+		IgnoreMissingEmbeds: ignoreMissingEmbeds,
+	}
+
+	if isTemplatePath(name) {
+		debugf("reading template %s", name)
+		src, err := readTemplateFile(name)
+		if err == nil && src == "" {
+			err = errors.New("template is empty")
+		}
+		if err != nil {
+			log.Printf("cannot load template %s: %v", name, err)
+			return nil, err
+		}
+		opts.Template, opts.TemplateSource = "", src
+	}
 
-var escape = nopstring
+	r, err := render.New(dataDirFS(dataDir), opts)
+	if err != nil {
+		log.Printf("%s: %v", dataDir, err)
+		return nil, err
+	}
+	return r, nil
+}
 
 const (
-	modeYAML   int = iota // Write a YAML file to the output path and exit
-	modeRender            // Parse YAML and render
+	modeYAML     int = iota // Write a YAML file to the output path and exit
+	modeRender              // Parse YAML and render
+	modeValidate            // Parse YAML and report problems without rendering
+	modeServe               // Render a YAML file over HTTP on each request
+	modeInit                // Write starter templates and an example YAML file
+	modeVersion             // Print the version and exit
+	modeSchema              // Print the fields available to templates and exit
 )
 
 func main() {
@@ -344,20 +1210,88 @@ func main() {
 	log.SetFlags(0)
 
 	useText := false
-	mainTemplate := "index.tem"
+	outputFormat := outputHTML
+	mainTemplate := ""
+	templateExt := ".tem"
 	outputPath := "-"
 	newline := true
+	useJSON := false
+	exportFormat := ""
+	exportSection := "work"
+	keepGoing := false
+	dryRun := false
+	check := false
+	jobs := 1
+	watch := false
+	recursive := false
+	bundle := false
+	stream := false
+	indentJSON := false
+	addr := ":8080"
+	noReload := false
+	force := false
+	minimal := false
+	delimsFlag := ""
+	linkPatternFlag := ""
+	showVersion := false
+	quiet := false
+	verbose := false
+	var readOpts readOptions
 
-	flag.StringVar(&mainTemplate, "template", mainTemplate, "the `template` to execute")
-	flag.StringVar(&dataDir, "data-dir", dataDir, "`directory` containing templates and other data")
-	flag.StringVar(&outputPath, "o", outputPath, "`path` to write output to. defaults to stdout (- or empty string).")
-	flag.BoolVar(&useText, "text", false, "whether to skip HTML-specific encoding in templates")
+	flag.StringVar(&mainTemplate, "template", mainTemplate, "the `template` to execute. defaults to index with the template extension (index.tem). may be a file path or - for stdin.")
+	flag.StringVar(&templateExt, "template-ext", templateExt, "the file `extension` of templates")
+	flag.StringVar(&dataDir, "data-dir", dataDir, "`directory` containing templates and other data. may be a .tar.gz or .tgz of that directory's contents.")
+	flag.StringVar(&outputPath, "o", outputPath, "`path` to write output to. defaults to stdout (- or empty string). may contain {{.Name}}, {{.Base}}, or {{.Dir}} to write each input to its own file.")
+	flag.StringVar(&outputFormat, "format", outputFormat, "output `format` of templates: html, text, or markdown")
+	flag.BoolVar(&useText, "text", false, "deprecated: the same as -format text")
 	flag.BoolVar(&newline, "newline", true, "whether to write a trailing newline at the end of each output (per YAML file)")
+	flag.BoolVar(&bundle, "bundle", false, "whether to inline the stylesheets, scripts, and images referred to by HTML output (render only)")
+	flag.BoolVar(&stream, "stream", false, "whether to write each file's output as it's rendered instead of holding it in memory. implies -no-trim. (render only)")
+	flag.BoolVar(&noTrim, "no-trim", false, "whether to leave leading and trailing whitespace in each rendered file instead of trimming it")
+	flag.BoolVar(&useJSON, "json", false, "whether to write each resume as JSON instead of rendering a template, or the schema as JSON with schema")
+	flag.StringVar(&exportFormat, "export", exportFormat, "`format` to export each resume as instead of rendering a template. may be jsonresume, csv, or tsv.")
+	flag.StringVar(&exportSection, "export-section", exportSection, "`section` to export as a table with -export csv or tsv (work, education, awards, publications, or references)")
+	flag.BoolVar(&indentJSON, "indent", false, "whether to pretty-print JSON output")
+	flag.StringVar(&readOpts.Format, "input-format", readOpts.Format, "`format` of resume files (yaml or toml). defaults to toml for .toml files and yaml otherwise.")
+	flag.BoolVar(&autolink, "autolink", autolink, "whether linkify also links bare URLs and email addresses")
+	flag.StringVar(&locale, "locale", "", "`locale` of month and weekday names written by dateLoc (en, de, fr, or es)")
+	flag.BoolVar(&ignoreMissingEmbeds, "ignore-missing-embeds", false, "whether embedding a file that doesn't exist logs a warning and embeds nothing instead of failing")
+	flag.BoolVar(&noLinkify, "no-linkify", false, "whether linkify leaves links alone, only escaping text")
+	flag.StringVar(&linkPatternFlag, "link-pattern", linkPatternFlag, "regular `expression` matching the links converted by linkify, instead of both ((URL label)) and [label](URL)")
+	flag.BoolVar(&keepGoing, "keep-going", false, "whether to continue rendering other files after one fails")
+	flag.BoolVar(&check, "check", false, "whether to compare output with the files already at its output paths instead of writing it, failing if any differ (render only)")
+	flag.BoolVar(&dryRun, "dry-run", false, "whether to report what would be written instead of writing it (render only)")
+	flag.IntVar(&jobs, "jobs", jobs, "`number` of files to render at once (render only)")
+	flag.StringVar(&rtype.OutputLayout, "date-layout", "", "`layout` to write all dates in YAML and JSON output with, as a Go time layout (e.g., 2006-01). defaults to the layout each date was written in.")
+	flag.StringVar(&delimsFlag, "delims", delimsFlag, "left and right template `delimiters`, separated by a space (e.g., \"[[ ]]\"). defaults to \"{{ }}\".")
+	flag.BoolVar(&recursive, "r", false, "whether directories given to render are searched recursively for resume files")
+	flag.BoolVar(&watch, "watch", false, "whether to watch templates and input files for changes and re-render them (render only)")
+	flag.StringVar(&addr, "addr", addr, "`address` to listen on (serve only)")
+	flag.BoolVar(&noReload, "no-reload", false, "whether to load templates once instead of again whenever they change (serve only)")
+	flag.BoolVar(&force, "force", false, "whether init may overwrite existing files")
+	flag.BoolVar(&minimal, "minimal", false, "whether to write a bare starter resume instead of the full example (yaml only)")
+	flag.BoolVar(&quiet, "quiet", false, "whether to log only errors")
+	flag.BoolVar(&verbose, "verbose", false, "whether to also log templates loaded, files embedded, and links rendered")
+	flag.BoolVar(&showVersion, "version", false, "print the version of resify and exit")
+	flag.BoolVar(&readOpts.Strict, "strict", false, "whether to reject unknown keys, including metadata, in resume files")
+	flag.BoolVar(&readOpts.Normalize, "normalize", false, "whether to fill empty fields that can be derived from others after reading resume files")
+	flag.BoolVar(&readOpts.ExpandEnv, "expand-env", false, "whether to replace ${VAR} in string values of resume files with the value of the environment variable VAR")
+	flag.BoolVar(&readOpts.RequireEnv, "require-env", false, "whether an unset environment variable is an error instead of expanding to an empty string (with -expand-env)")
+	flag.Var(extra, "set", "`key=value` to give templates as .Extra.key. may be repeated.")
+	flag.Var((*tagList)(&readOpts.Tags.Include), "tag", "`tag` of list entries to keep; tagged entries without any of these are left out. may be repeated or list several tags separated by commas.")
+	flag.Var((*tagList)(&readOpts.Tags.Exclude), "exclude-tag", "`tag` of list entries to leave out, even if they have a tag given by -tag. may be repeated or list several tags separated by commas.")
+	flag.BoolVar(&readOpts.Tags.TaggedOnly, "tagged-only", false, "whether to leave out list entries without tags")
+	flag.Var((*sectionList)(&readOpts.Omit), "omit", "`section` to leave out of resumes after reading them (e.g., references). may be repeated or list several sections separated by commas.")
 	flag.Parse()
 
+	if showVersion {
+		fmt.Println(versionString())
+		return
+	}
+
 	if flag.NArg() == 0 {
-		log.Println("no command given, exiting with status 1")
-		rc = 1
+		log.Println("no command given, exiting with status 2")
+		rc = exitUsage
 		return
 	}
 
@@ -367,114 +1301,335 @@ func main() {
 		mode = modeRender
 	case "yaml":
 		mode = modeYAML
+	case "validate":
+		mode = modeValidate
+	case "serve":
+		mode = modeServe
+	case "init":
+		mode = modeInit
+	case "version":
+		mode = modeVersion
+	case "schema":
+		mode = modeSchema
 	default:
 		log.Printf("unrecognized command: %q", flag.Arg(0))
-		rc = 1
+		rc = exitUsage
 		return
 	}
 
-	var output io.Writer = os.Stdout
-	switch outputPath {
-	case "", "-":
-	// Stdout - default
-	default:
-		if fi, err := os.Create(outputPath); err != nil {
-			log.Printf("cannot open %s for writing: %v", outputPath, err)
-			rc = 1
+	// Parse any flags following the command.
+	flag.CommandLine.Parse(flag.Args()[1:])
+
+	if mode == modeVersion || showVersion {
+		fmt.Println(versionString())
+		return
+	}
+
+	switch {
+	case quiet && verbose:
+		log.Println("-quiet and -verbose cannot be used together")
+		rc = exitUsage
+		return
+	case quiet:
+		logLevel = logQuiet
+	case verbose:
+		logLevel = logVerbose
+	}
+
+	if _, err := inputFormat("", readOpts.Format); err != nil {
+		log.Println(err)
+		rc = exitUsage
+		return
+	}
+
+	d, err := parseDelims(delimsFlag)
+	if err != nil {
+		log.Println(err)
+		rc = exitUsage
+		return
+	}
+	delims = d
+
+	if linkPatternFlag != "" {
+		if linkPattern, err = regexp.Compile(linkPatternFlag); err != nil {
+			log.Printf("cannot parse link pattern: %v", err)
+			rc = exitUsage
 			return
-		} else {
-			defer func() {
-				if err := fi.Close(); err != nil {
-					log.Printf("warning: unable to close %s on shutdown: %v", err)
-				}
-			}()
-			output = fi
 		}
 	}
 
-	defer func() {
-		if rc != 1 && newline {
-			io.WriteString(output, "\n")
+	// -text is an alias for -format text, and can't be given with any other format.
+	if useText {
+		formatGiven := false
+		flag.Visit(func(f *flag.Flag) { formatGiven = formatGiven || f.Name == "format" })
+		if formatGiven && outputFormat != outputText {
+			log.Printf("-text cannot be given with -format %s", outputFormat)
+			rc = exitUsage
+			return
 		}
-	}()
+		outputFormat = outputText
+	}
 
-	if mode == modeYAML {
-		if err := generateYAML(output); err != nil {
-			rc = 1
+	switch outputFormat {
+	case outputHTML:
+	case outputText:
+		useText = true
+	case outputMarkdown:
+		useText, markdownOutput = true, true
+	default:
+		log.Printf("unrecognized output format: %q; must be html, text, or markdown", outputFormat)
+		rc = exitUsage
+		return
+	}
+
+	if templateExt = normalizeExt(templateExt); templateExt == "" {
+		log.Println("template extension cannot be empty")
+		rc = exitUsage
+		return
+	}
+
+	if mode == modeValidate {
+		args := flag.Args()
+		if len(args) == 0 {
+			args = []string{"-"}
+		}
+		readOpts.KeepBadDates = true
+		if !validateFiles(args, readOpts) {
+			rc = exitParse
 		}
 		return
 	}
 
-	if useText {
-		tx, err := textt.New("root").
-			Funcs(map[string]interface{}{
-				"embed":   readFile,
-				"html":    nopstring,
-				"attr":    nopstring,
-				"css":     nopstring,
-				"js":      nopstring,
-				"linkify": linkify,
-			}).
-			ParseGlob(filepath.Join(dataDir, "*.tem"))
+	if mode == modeInit {
+		if err := initProject(templateExt, force); err != nil {
+			rc = exitIO
+		}
+		return
+	}
 
+	// Unless -data-dir is given, the templates directory may be found in a parent of the working directory.
+	dataDirGiven := false
+	flag.Visit(func(f *flag.Flag) { dataDirGiven = dataDirGiven || f.Name == "data-dir" })
+	if !dataDirGiven {
+		dataDir = findDataDir(dataDir)
+		debugf("using templates directory %s", dataDir)
+	} else if isTarball(dataDir) {
+		dir, err := extractTarball(dataDir)
 		if err != nil {
-			log.Println("error parsing template as text:", err)
-			rc = 1
+			log.Printf("cannot extract %s: %v", dataDir, err)
+			rc = exitIO
 			return
 		}
+		defer os.RemoveAll(dir)
+		removeOnSignal(dir)
+		debugf("extracted %s to %s", dataDir, dir)
+		dataDir = dir
+	}
 
-		formatter = tx
-	} else {
-		tx, err := htmlt.New("root").
-			Funcs(map[string]interface{}{
-				"embed":    readFile,
-				"html":     func(s string) htmlt.HTML { return htmlt.HTML(s) },
-				"attr":     func(s string) htmlt.HTMLAttr { return htmlt.HTMLAttr(s) },
-				"css":      func(s string) htmlt.CSS { return htmlt.CSS(s) },
-				"js":       func(s string) htmlt.JS { return htmlt.JS(s) },
-				"linkify":  func(s string) htmlt.HTML { return htmlt.HTML(linkify(s)) },
-				"markdown": func(s string) htmlt.HTML { return htmlt.HTML(blackfriday.Run([]byte(s))) },
-			}).
-			ParseGlob(filepath.Join(dataDir, "*.tem"))
+	if mode == modeServe {
+		if flag.NArg() != 1 || flag.Arg(0) == "-" {
+			log.Println("serve requires exactly one resume file")
+			rc = exitUsage
+			return
+		}
+
+		handler := &previewHandler{
+			path:     flag.Arg(0),
+			ext:      templateExt,
+			template: mainTemplate,
+			useText:  useText,
+			opts:     readOpts,
+			noReload: noReload,
+		}
+		if noReload {
+			if _, err := handler.loadRenderer(); err != nil {
+				rc = exitTemplate
+				return
+			}
+		}
+		infof("serving %s on %s", flag.Arg(0), addr)
+		if err := http.ListenAndServe(addr, newPreviewServer(handler)); err != nil {
+			log.Println("cannot serve:", err)
+			rc = exitIO
+		}
+		return
+	}
 
+	pattern, err := parseOutputPattern(outputPath)
+	if err != nil {
+		log.Printf("cannot parse output path %q: %v", outputPath, err)
+		rc = exitUsage
+		return
+	} else if pattern != nil && mode != modeRender {
+		log.Printf("output path %q is a pattern, but patterns are only supported by render", outputPath)
+		rc = exitUsage
+		return
+	}
+
+	if mode == modeYAML || mode == modeSchema {
+		out, err := createOutput(outputPath)
 		if err != nil {
-			log.Println("error parsing template as html:", err)
-			rc = 1
+			rc = exitIO
 			return
 		}
 
-		escape = htmlt.HTMLEscapeString
-		formatter = tx
+		if mode == modeSchema {
+			err = writeSchema(out, useJSON, indentJSON)
+		} else {
+			err = generateYAML(out, minimal)
+		}
+
+		// Write errors are logged by out, and only end it with a newline if there were none.
+		if cerr := out.Close(newline && err == nil); err != nil || cerr != nil {
+			rc = exitIO
+		}
+		return
 	}
 
-	args := flag.Args()[1:]
+	args := flag.Args()
 	if len(args) == 0 {
 		args = []string{"-"}
 	}
+	if args, err = expandInputs(args, recursive); err != nil {
+		log.Println(err)
+		rc = exitParse
+		return
+	}
+	debugf("rendering %s", strings.Join(args, ", "))
 
-	for _, arg := range args {
-		resume, err := readResumeFromFile(arg)
-		if err != nil {
-			rc = 1
+	useCSV := false
+	switch exportFormat {
+	case "":
+	case exportJSONResume:
+		// Exporting is JSON output with a different schema.
+		useJSON = true
+	case exportCSV, exportTSV:
+		useCSV = true
+		if _, ok := csvSections[exportSection]; !ok {
+			log.Printf("cannot export section %q as a table; must be one of %s", exportSection, strings.Join(csvSectionNames(), ", "))
+			rc = exitUsage
+			return
+		}
+		// Tables written one after the other would run together, so each needs its own output.
+		if len(args) > 1 && pattern == nil && !dryRun {
+			log.Printf("-export %s needs an output pattern, such as -o '{{.Base}}.%[1]s', to export more than one file", exportFormat)
+			rc = exitUsage
 			return
 		}
+	default:
+		log.Printf("unrecognized export format: %q", exportFormat)
+		rc = exitUsage
+		return
+	}
+	useTemplate := !useJSON && !useCSV
 
-		var buf bytes.Buffer
-		if err = formatter.ExecuteTemplate(&buf, mainTemplate, resume); err != nil {
-			log.Println("cannot execute template:", err)
-			rc = 1
+	if check && (dryRun || watch) {
+		log.Println("-check cannot be used with -dry-run or -watch")
+		rc = exitUsage
+		return
+	}
+	if bundle && stream {
+		log.Println("-bundle cannot be used with -stream, since it needs the whole output")
+		rc = exitUsage
+		return
+	}
+	// Streamed output is written before it could be trimmed.
+	noTrim = noTrim || stream
+
+	if bundle && (useText || !useTemplate) {
+		log.Println("-bundle can only be used with HTML output")
+		rc = exitUsage
+		return
+	}
+
+	if jobs < 1 {
+		log.Printf("-jobs must be at least 1; got %d", jobs)
+		rc = exitUsage
+		return
+	}
+
+	for _, arg := range args {
+		if arg != "-" && arg != "" {
+			continue
+		}
+		if watch {
+			log.Println("cannot watch stdin for changes")
+			rc = exitUsage
+			return
+		} else if mainTemplate == "-" && useTemplate {
+			log.Println("cannot read both the template and a resume from stdin")
+			rc = exitUsage
 			return
 		}
+	}
 
-		b := bytes.Trim(buf.Bytes(), whitespace)
-		for len(b) > 0 {
-			n, err := output.Write(b)
-			if err != nil && err != io.ErrShortWrite {
-				log.Println("cannot write to output:", err)
-				rc = 1
-				return
-			}
-			b = b[n:]
+	run := &renderRun{
+		args:         args,
+		readOpts:     readOpts,
+		useText:      useText,
+		ext:          templateExt,
+		template:     mainTemplate,
+		useJSON:      useJSON,
+		useCSV:       useCSV,
+		exportFormat: exportFormat,
+		section:      exportSection,
+		indentJSON:   indentJSON,
+
+		outputPath:    outputPath,
+		defaultOutput: outputPath,
+		newline:       newline,
+		bundle:        bundle,
+		stream:        stream,
+		dryRun:        dryRun,
+		check:         check,
+		keepGoing:     keepGoing,
+		jobs:          jobs,
+		pattern:       pattern,
+	}
+	flag.Visit(func(f *flag.Flag) { run.outputGiven = run.outputGiven || f.Name == "o" })
+
+	if !watch {
+		rc = run.renderAll()
+		return
+	}
+
+	// Files included by the inputs are watched too. They're found again by each render, since includes may change.
+	var includedMu sync.Mutex
+	included := map[string]bool{}
+	run.readOpts.Included = func(path string) {
+		includedMu.Lock()
+		defer includedMu.Unlock()
+		included[path] = true
+	}
+
+	rerender := func() {
+		includedMu.Lock()
+		included = map[string]bool{}
+		includedMu.Unlock()
+
+		if run.renderAll() == exitOK {
+			infof("rendered %d file(s)", len(args))
+		} else {
+			log.Println("render failed; waiting for changes")
+		}
+	}
+
+	watched := append([]string{dataDir}, args...)
+	if mainTemplate != "-" && isTemplatePath(mainTemplate) {
+		watched = append(watched, mainTemplate)
+	}
+	watchedFiles := func() []string {
+		includedMu.Lock()
+		defer includedMu.Unlock()
+		paths := append([]string(nil), watched...)
+		for path := range included {
+			paths = append(paths, path)
 		}
+		return paths
 	}
+
+	// Renders and errors are logged with the time they happened, since watching may go on for a long time.
+	log.SetFlags(log.Ltime)
+	rerender()
+	watchFiles(watchedFiles, watchInterval, watchSettle, rerender)
 }
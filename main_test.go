@@ -0,0 +1,579 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/fs"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/nilium/resify/render"
+	"github.com/nilium/resify/rtype"
+)
+
+// mainArgsEnv is the environment variable that, when set, makes the test binary run main with its newline-separated
+// arguments instead of running tests. See runMain.
+const mainArgsEnv = "RESIFY_TEST_MAIN_ARGS"
+
+func TestMain(m *testing.M) {
+	if args, ok := os.LookupEnv(mainArgsEnv); ok {
+		os.Args = append([]string{"resify"}, strings.Split(args, "\n")...)
+		main()
+	}
+	os.Exit(m.Run())
+}
+
+// mainCommand returns a command that runs resify with args in dir, in a new process.
+func mainCommand(t *testing.T, dir string, args ...string) *exec.Cmd {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(exe)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), mainArgsEnv+"="+strings.Join(args, "\n"))
+	return cmd
+}
+
+// runMain runs resify with args in dir, in a new process, and returns what it logged and its exit code.
+func runMain(t *testing.T, dir string, args ...string) (logged string, rc int) {
+	t.Helper()
+	var stderr bytes.Buffer
+	cmd := mainCommand(t, dir, args...)
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		return stderr.String(), exit.ExitCode()
+	} else if err != nil {
+		t.Fatalf("cannot run resify %q: %v", args, err)
+	}
+	return stderr.String(), exitOK
+}
+
+func TestKeepGoing(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"templates/index.tem": "{{ .Me.Chosen }}",
+		"bad.yaml":            "me: [\n",
+		"good.yaml":           "me: {chosen: Good}\n",
+	})
+	good := filepath.Join(dir, "out", "good.txt")
+
+	// Without -keep-going, rendering stops at the first failure.
+	logged, rc := runMain(t, dir, "render", "-o", "out/{{.Base}}.txt", "bad.yaml", "good.yaml")
+	if rc != exitParse {
+		t.Errorf("exit code = %d; want %d\n%s", rc, exitParse, logged)
+	}
+	if _, err := os.Stat(good); !os.IsNotExist(err) {
+		t.Errorf("expected good.yaml not to be rendered after bad.yaml failed; stat: %v", err)
+	}
+
+	logged, rc = runMain(t, dir, "render", "-keep-going", "-o", "out/{{.Base}}.txt", "bad.yaml", "good.yaml")
+	if rc != exitParse {
+		t.Errorf("exit code with -keep-going = %d; want %d\n%s", rc, exitParse, logged)
+	}
+	if !strings.Contains(logged, "failed to render 1 of 2 files: bad.yaml") {
+		t.Errorf("expected the failed file to be named; got log:\n%s", logged)
+	}
+	if b, err := os.ReadFile(good); err != nil || string(b) != "Good\n" {
+		t.Errorf("expected good.yaml to be rendered with -keep-going; got %q, %v", b, err)
+	}
+}
+
+func TestDuplicateOutputPaths(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"templates/index.tem": "{{ .Me.Chosen }}",
+		"people/a/me.yaml":    "me: {chosen: A}\n",
+		"people/b/me.yaml":    "me: {chosen: B}\n",
+	})
+
+	logged, rc := runMain(t, dir, "render", "-r", "-o", "out/{{.Base}}.txt", "people")
+	if rc != exitUsage || !strings.Contains(logged, "people/a/me.yaml and people/b/me.yaml would both be written to out/me.txt") {
+		t.Errorf("exit code = %d; want %d with both inputs named\n%s", rc, exitUsage, logged)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out")); !os.IsNotExist(err) {
+		t.Errorf("expected nothing to be written; stat: %v", err)
+	}
+
+	if logged, rc := runMain(t, dir, "render", "-r", "-o", "out/{{.Dir}}/{{.Base}}.txt", "people"); rc != exitOK {
+		t.Fatalf("exit code = %d; want %d\n%s", rc, exitOK, logged)
+	}
+	for path, want := range map[string]string{"out/people/a/me.txt": "A\n", "out/people/b/me.txt": "B\n"} {
+		if b, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(path))); err != nil || string(b) != want {
+			t.Errorf("%s = %q, %v; want %q", path, b, err, want)
+		}
+	}
+}
+
+func TestCheckPattern(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"templates/index.tem": "{{ .Me.Chosen }}",
+		"a.yaml":              "me: {chosen: A}\n",
+		"b.yaml":              "me: {chosen: B}\n",
+		"c.yaml":              "me: {chosen: C}\n",
+		"out/a.txt":           "Old A\n",
+		"out/b.txt":           "B\n",
+	})
+
+	for _, args := range [][]string{
+		{"render", "-check", "-o", "out/{{.Base}}.txt", "a.yaml", "b.yaml", "c.yaml"},
+		{"render", "-check", "-keep-going", "-o", "out/{{.Base}}.txt", "a.yaml", "b.yaml", "c.yaml"},
+	} {
+		logged, rc := runMain(t, dir, args...)
+		if rc != exitStale {
+			t.Errorf("%q: exit code = %d; want %d\n%s", args, rc, exitStale, logged)
+		}
+		for _, want := range []string{"out/a.txt is out of date", "out/c.txt does not exist", "2 of 3 outputs are stale"} {
+			if !strings.Contains(logged, want) {
+				t.Errorf("%q: expected %q in output:\n%s", args, want, logged)
+			}
+		}
+		if strings.Contains(logged, "failed to render") {
+			t.Errorf("%q: expected stale outputs not to be reported as failures:\n%s", args, logged)
+		}
+	}
+}
+
+func TestStream(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"templates/index.tem": "{{ .Me.Chosen }}{{ if .Meta.fail }}{{ .Me.Missing }}{{ end }}",
+		"good.yaml":           "me: {chosen: Good}\n",
+		"bad.yaml":            "me: {chosen: Bad}\nfail: true\n",
+		"out/bad.txt":         "left over from an earlier render",
+	})
+	readOut := func(name string) string {
+		t.Helper()
+		b, err := os.ReadFile(filepath.Join(dir, "out", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	if logged, rc := runMain(t, dir, "render", "-stream", "-o", "out/all.txt", "good.yaml", "good.yaml"); rc != exitOK {
+		t.Fatalf("exit code = %d; want %d\n%s", rc, exitOK, logged)
+	}
+	if got, want := readOut("all.txt"), "GoodGood\n"; got != want {
+		t.Errorf("all.txt = %q; want %q", got, want)
+	}
+
+	if logged, rc := runMain(t, dir, "render", "-stream", "-o", "out/{{.Base}}.txt", "good.yaml"); rc != exitOK {
+		t.Fatalf("exit code = %d; want %d\n%s", rc, exitOK, logged)
+	}
+	if got, want := readOut("good.txt"), "Good\n"; got != want {
+		t.Errorf("good.txt = %q; want %q", got, want)
+	}
+
+	// Without -stream, a file that fails to render leaves its output as it was.
+	logged, rc := runMain(t, dir, "render", "-o", "out/{{.Base}}.txt", "bad.yaml")
+	if rc != exitTemplate {
+		t.Errorf("exit code = %d; want %d\n%s", rc, exitTemplate, logged)
+	}
+	if got, want := readOut("bad.txt"), "left over from an earlier render"; got != want {
+		t.Errorf("bad.txt without -stream = %q; want %q", got, want)
+	}
+
+	// With -stream, the output is truncated before rendering, so it's left holding what was rendered before the error,
+	// without a trailing newline.
+	logged, rc = runMain(t, dir, "render", "-stream", "-o", "out/{{.Base}}.txt", "bad.yaml")
+	if rc != exitTemplate {
+		t.Errorf("exit code with -stream = %d; want %d\n%s", rc, exitTemplate, logged)
+	}
+	if !strings.Contains(logged, "cannot execute template") {
+		t.Errorf("expected the template error to be logged; got log:\n%s", logged)
+	}
+	if got, want := readOut("bad.txt"), "Bad"; got != want {
+		t.Errorf("bad.txt with -stream = %q; want %q", got, want)
+	}
+}
+
+// BenchmarkRender compares rendering a large resume in memory, as render does by default, with streaming it to its output,
+// as render -stream does.
+func BenchmarkRender(b *testing.B) {
+	resume := rtype.Resume{Me: rtype.Me{Chosen: "Jane"}}
+	for i := 0; i < 10000; i++ {
+		resume.Employment = append(resume.Employment, rtype.Employment{
+			Title:       "Engineer " + strconv.Itoa(i),
+			Description: strings.Repeat("Built and maintained things. ", 10),
+			Highlights:  []string{"Shipped", "Reviewed", "Mentored"},
+		})
+	}
+	r, err := render.New(fstest.MapFS{}, render.Options{
+		Text:           true,
+		Ext:            ".tem",
+		TemplateSource: "{{ .Me.Chosen }}\n{{ range .Employment }}\n{{ .Title }}\n{{ .Description }}\n{{ range .Highlights }}- {{ . }}\n{{ end }}{{ end }}",
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	data := render.Data{Resume: resume}
+	out := filepath.Join(b.TempDir(), "resume.txt")
+
+	b.Run("buffered", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var buf bytes.Buffer
+			if err := r.Execute(&buf, data); err != nil {
+				b.Fatal(err)
+			}
+			if err := writeOutputFile(out, trimOutput(buf.Bytes()), true); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("stream", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			o, err := createOutput(out)
+			if err != nil {
+				b.Fatal(err)
+			}
+			bw := bufio.NewWriter(o)
+			if err := r.Execute(bw, data); err != nil {
+				b.Fatal(err)
+			}
+			if err := bw.Flush(); err != nil {
+				b.Fatal(err)
+			}
+			if err := o.Close(true); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestDataDirFSSymlinkEscape(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"templates/style.css": "body {}",
+		"secret.txt":          "secret",
+	})
+
+	templates := filepath.Join(dir, "templates")
+	links := map[string]string{
+		"escape.txt": filepath.Join(dir, "secret.txt"),
+		"parent":     dir,
+		"inside.css": filepath.Join(templates, "style.css"),
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(templates, name)); err != nil {
+			t.Skipf("cannot create symlinks: %v", err)
+		}
+	}
+
+	fsys := dataDirFS(templates)
+	for _, path := range []string{"escape.txt", "parent/secret.txt"} {
+		if got, err := fs.ReadFile(fsys, path); err != render.ErrEscapeAttempt {
+			t.Errorf("ReadFile(%q) = %q, %v; want %v", path, got, err, render.ErrEscapeAttempt)
+		}
+	}
+
+	if got, err := fs.ReadFile(fsys, "../secret.txt"); err == nil {
+		t.Errorf("ReadFile(%q) = %q; want an error", "../secret.txt", got)
+	}
+
+	if got, err := fs.ReadFile(fsys, "inside.css"); err != nil || string(got) != "body {}" {
+		t.Errorf("ReadFile(%q) = %q, %v; want %q", "inside.css", got, err, "body {}")
+	}
+}
+
+func TestParseOmit(t *testing.T) {
+	table := []struct {
+		in   string
+		want []string
+		ok   bool
+	}{
+		{"", nil, true},
+		{"references", []string{"references"}, true},
+		{" references, awards ,", []string{"references", "awards"}, true},
+		{"references,hobbies", nil, false},
+	}
+
+	for _, e := range table {
+		got, err := parseOmit(e.in)
+		if (err == nil) != e.ok || !reflect.DeepEqual(got, e.want) {
+			t.Errorf("parseOmit(%q) = %q, %v; expected %q (ok: %v)", e.in, got, err, e.want, e.ok)
+		}
+	}
+}
+
+func TestSectionList(t *testing.T) {
+	var l sectionList
+	for _, s := range []string{"education", "awards,references"} {
+		if err := l.Set(s); err != nil {
+			t.Fatalf("Set(%q) = %v; want no error", s, err)
+		}
+	}
+	if want := (sectionList{"education", "awards", "references"}); !reflect.DeepEqual(l, want) {
+		t.Errorf("expected sections %q; got %q", want, l)
+	}
+
+	if err := l.Set("projects"); err == nil {
+		t.Error("expected an error for an unknown section")
+	}
+	if got := l.String(); got != "education,awards,references" {
+		t.Errorf("String() = %q; expected the sections joined by commas", got)
+	}
+}
+
+func TestReadResumeOmit(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"resume.yaml": "me: {chosen: Me}\ninclude: [refs.yaml]\nreferences:\n- name: First\n",
+		"refs.yaml":   "references:\n- name: Second\n",
+	})
+
+	r, err := readResumeFromFile(filepath.Join(dir, "resume.yaml"), readOptions{Omit: []string{"references"}})
+	if err != nil {
+		t.Fatalf("unexpected error reading resume: %v", err)
+	}
+	if r.References != nil || r.Me.Chosen != "Me" {
+		t.Errorf("expected references omitted and other sections kept; got %+v", r)
+	}
+}
+
+func TestReadResumeTags(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"resume.yaml": "work:\n- {title: Go, tags: [backend, go]}\n- {title: Lead, tags: [Backend, leadership]}\n- {title: Untagged}\n" +
+			"include: [more.yaml]\n",
+		"more.yaml": "work:\n- {title: Design, tags: [frontend]}\n",
+	})
+
+	var opts readOptions
+	for flag, s := range map[*tagList]string{
+		(*tagList)(&opts.Tags.Include): "backend, ",
+		(*tagList)(&opts.Tags.Exclude): "leadership",
+	} {
+		if err := flag.Set(s); err != nil {
+			t.Fatalf("Set(%q) = %v; want no error", s, err)
+		}
+	}
+
+	r, err := readResumeFromFile(filepath.Join(dir, "resume.yaml"), opts)
+	if err != nil {
+		t.Fatalf("unexpected error reading resume: %v", err)
+	}
+	var titles []string
+	for _, e := range r.Employment {
+		titles = append(titles, e.Title)
+	}
+	if want := []string{"Go", "Untagged"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("expected work %q; got %q", want, titles)
+	}
+}
+
+func TestInputFormat(t *testing.T) {
+	table := []struct {
+		path, format string
+		want         string
+		err          bool
+	}{
+		{"resume.toml", "", formatTOML, false},
+		{"RESUME.TOML", "", formatTOML, false},
+		{"resume.yaml", "", formatYAML, false},
+		{"resume.yml", "", formatYAML, false},
+		{"resume.toml.bak", "", formatYAML, false},
+		{"-", "", formatYAML, false},
+		{"", "", formatYAML, false},
+		{"resume.yaml", formatTOML, formatTOML, false},
+		{"resume.toml", formatYAML, formatYAML, false},
+		{"resume.toml", "json", "", true},
+	}
+
+	for _, e := range table {
+		got, err := inputFormat(e.path, e.format)
+		if (err != nil) != e.err || got != e.want {
+			t.Errorf("inputFormat(%q, %q) = %q, %v; want %q (error: %t)", e.path, e.format, got, err, e.want, e.err)
+		}
+	}
+}
+
+func TestReadResumeTOML(t *testing.T) {
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"resume.toml": `
+[me]
+chosen = "Jane"
+email = "jane@example.com"
+
+[profiles]
+".order" = ["github"]
+github = { url = "https://github.com/jane", label = "GitHub" }
+
+[[work]]
+title = "Engineer"
+manager = "Damien"
+where = { name = "Foobiz" }
+when = { from = 2010-08-01, to = 2015-12-31T09:30:00-05:00 }
+
+[[work]]
+title = "Lead"
+when = { from = "2016-03" }
+`,
+		"resume.yaml": `
+me:
+  chosen: Jane
+  email: jane@example.com
+profiles:
+  .order: [github]
+  github: {url: "https://github.com/jane", label: GitHub}
+work:
+- title: Engineer
+  manager: Damien
+  where: {name: Foobiz}
+  when: {from: 2010-08-01, to: 2015-12-31 09:30:00 -0500}
+- title: Lead
+  when: {from: 2016-03}
+`,
+		// Formats given by -input-format override the extension.
+		"toml.txt":  "[me]\nchosen = \"Jane\"\n",
+		"yaml.toml": "me: {chosen: Jane}\n",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fromTOML, err := readResumeFromFile(filepath.Join(dir, "resume.toml"), readOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error reading TOML resume: %v", err)
+	}
+	fromYAML, err := readResumeFromFile(filepath.Join(dir, "resume.yaml"), readOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error reading YAML resume: %v", err)
+	}
+	if !reflect.DeepEqual(fromTOML, fromYAML) {
+		t.Errorf("TOML resume differs from the same resume in YAML:\ngot  %+v\nwant %+v", fromTOML, fromYAML)
+	}
+
+	// Local dates become dates and offset datetimes keep their time and offset.
+	when := fromTOML.Employment[0].When
+	if want := time.Date(2010, 8, 1, 0, 0, 0, 0, time.UTC); !when.From.Equal(want) {
+		t.Errorf("work[0].when.from = %v; want %v", when.From, want)
+	}
+	if want := time.Date(2015, 12, 31, 14, 30, 0, 0, time.UTC); !when.To.Equal(want) {
+		t.Errorf("work[0].when.to = %v; want %v", when.To, want)
+	}
+	if _, offset := when.To.Zone(); offset != -5*60*60 {
+		t.Errorf("work[0].when.to has offset %d; want -05:00", offset)
+	}
+	if got, want := when.Format("2006-01-02"), "2010-08-01 - 2015-12-31"; got != want {
+		t.Errorf("work[0].when formatted as %q; want %q", got, want)
+	}
+
+	for _, e := range []struct {
+		file, format string
+	}{
+		{"toml.txt", formatTOML},
+		{"yaml.toml", formatYAML},
+	} {
+		r, err := readResumeFromFile(filepath.Join(dir, e.file), readOptions{Format: e.format})
+		if err != nil || r.Me.Chosen != "Jane" {
+			t.Errorf("reading %s as %s: chosen = %q, %v; want %q", e.file, e.format, r.Me.Chosen, err, "Jane")
+		}
+	}
+	if _, err := readResumeFromFile(filepath.Join(dir, "yaml.toml"), readOptions{}); err == nil {
+		t.Errorf("expected an error reading YAML from a .toml file without an input format")
+	}
+
+	// Errors decoding a TOML resume name TOML, even though it's decoded as YAML once converted.
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	if err := ioutil.WriteFile(filepath.Join(dir, "bad.toml"), []byte("me = \"Jane\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readResumeFromFile(filepath.Join(dir, "bad.toml"), readOptions{}); err == nil {
+		t.Errorf("expected an error reading a TOML resume with the wrong types")
+	} else if !strings.Contains(logs.String(), "bad.toml as TOML:") {
+		t.Errorf("expected the error to name TOML; got log:\n%s", logs.String())
+	}
+}
+
+func TestGenerateYAML(t *testing.T) {
+	for _, minimal := range []bool{false, true} {
+		var buf bytes.Buffer
+		if err := generateYAML(&buf, minimal); err != nil {
+			t.Fatalf("unexpected error generating YAML (minimal=%t): %v", minimal, err)
+		}
+
+		path := filepath.Join(t.TempDir(), "resume.yaml")
+		if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := readResumeFromFile(path, readOptions{Strict: minimal})
+		if err != nil {
+			t.Fatalf("cannot read generated YAML (minimal=%t): %v", minimal, err)
+		}
+		if problems := validateResume(r); len(problems) > 0 {
+			t.Errorf("generated YAML (minimal=%t) has problems: %v", minimal, problems)
+		}
+		if len(r.Employment) != 1 || len(r.Education) != 1 || len(r.Awards) != 1 || len(r.Publications) != 1 ||
+			len(r.References) != 1 || len(r.Profiles.Profile) == 0 {
+			t.Errorf("expected an entry in each section of generated YAML (minimal=%t); got %+v", minimal, r)
+		}
+	}
+}
+
+func TestExtraValues(t *testing.T) {
+	m := extraValues{}
+	for _, s := range []string{"company=Foobiz", "url=https://example.com/?a=b", "company=Barco", "empty="} {
+		if err := m.Set(s); err != nil {
+			t.Errorf("unexpected error setting %q: %v", s, err)
+		}
+	}
+
+	want := extraValues{"company": "Barco", "url": "https://example.com/?a=b", "empty": ""}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("expected %v; got %v", want, m)
+	}
+	if s, want := m.String(), "company=Barco,empty=,url=https://example.com/?a=b"; s != want {
+		t.Errorf("String() = %q; want %q", s, want)
+	}
+
+	for _, s := range []string{"company", "=Foobiz", ""} {
+		if err := m.Set(s); err == nil {
+			t.Errorf("expected error setting %q", s)
+		}
+	}
+}
+
+func TestSourceModTime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "resume.yaml")
+	if err := os.WriteFile(path, []byte("me: {chosen: Me}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := sourceModTime(path); !got.Equal(modTime) {
+		t.Errorf("sourceModTime(%s) = %v; want %v", path, got, modTime)
+	}
+	for _, path := range []string{"-", "", filepath.Join(t.TempDir(), "missing.yaml")} {
+		if got := sourceModTime(path); !got.IsZero() {
+			t.Errorf("sourceModTime(%q) = %v; want zero time", path, got)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	textt "text/template"
+)
+
+// outputName is the data given to an output path pattern for each input file.
+type outputName struct {
+	Name string // The input's file name without its directory, such as "jane.yaml". Stdin is named "stdin".
+	Base string // The input's file name without its extension, such as "jane".
+	Dir  string // The input's directory as given, with slashes, such as "resumes/a". It's "." for stdin.
+}
+
+// noTrim controls whether trimOutput leaves rendered output as it is.
+var noTrim bool
+
+// trimOutput returns b, the output rendered from one resume, with leading and trailing whitespace removed, unless noTrim is
+// set. Trailing newlines are added after trimming, if at all, so they don't depend on noTrim.
+func trimOutput(b []byte) []byte {
+	if noTrim {
+		return b
+	}
+	return bytes.Trim(b, whitespace)
+}
+
+// parseOutputPattern parses an output path containing template actions, such as "out/{{.Base}}.html", that gives the path
+// to write each input's output to. If path contains no actions, it isn't a pattern and parseOutputPattern returns nil.
+func parseOutputPattern(path string) (*textt.Template, error) {
+	if !strings.Contains(path, "{{") {
+		return nil, nil
+	}
+	return textt.New("output").Option("missingkey=error").Parse(path)
+}
+
+// expandOutputPattern returns the output path given by pattern for the input file at path.
+func expandOutputPattern(pattern *textt.Template, path string) (string, error) {
+	name := outputName{Name: "stdin", Base: "stdin", Dir: "."}
+	if path != "-" && path != "" {
+		name.Name = filepath.Base(path)
+		name.Base = strings.TrimSuffix(name.Name, filepath.Ext(name.Name))
+		name.Dir = filepath.ToSlash(filepath.Dir(path))
+	}
+
+	var buf bytes.Buffer
+	if err := pattern.Execute(&buf, name); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// checkOutputPaths returns an error if pattern gives more than one of inputs the same output path, since each would
+// overwrite the output of the one before it. Inputs whose output paths can't be expanded are left to fail when rendered.
+func checkOutputPaths(pattern *textt.Template, inputs []string) error {
+	seen := make(map[string]string, len(inputs))
+	for _, in := range inputs {
+		out, err := expandOutputPattern(pattern, in)
+		if err != nil {
+			continue
+		}
+		if prev, ok := seen[filepath.Clean(out)]; ok {
+			return fmt.Errorf("%s and %s would both be written to %s", prev, in, out)
+		}
+		seen[filepath.Clean(out)] = in
+	}
+	return nil
+}
+
+// outputAction returns what writing to the file at path would do to it: "create" it if it doesn't exist, or "overwrite" it
+// if it does.
+func outputAction(path string) string {
+	if _, err := os.Stat(path); err == nil {
+		return "overwrite"
+	}
+	return "create"
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// openOutput opens the output at path for writing, creating or truncating it. If path is "-" or empty, the output is
+// stdout, which is not closed by the returned WriteCloser.
+func openOutput(path string) (io.WriteCloser, error) {
+	if path == "" || path == "-" {
+		return nopWriteCloser{os.Stdout}, nil
+	}
+	return os.Create(path)
+}
+
+// outputWriter is an output opened by createOutput, which may be stdout. It owns the output, writing to it, ending it with
+// a newline, and closing it, and logs any error doing so with the output's path.
+type outputWriter struct {
+	path string // The path of the output, or "-" for stdout.
+	w    io.WriteCloser
+	err  error // The first error writing to w.
+}
+
+// createOutput opens the output at path for writing (see openOutput). Errors are logged before being returned.
+func createOutput(path string) (*outputWriter, error) {
+	w, err := openOutput(path)
+	if err != nil {
+		log.Printf("cannot open %s for writing: %v", path, err)
+		return nil, err
+	}
+	return &outputWriter{path: path, w: w}, nil
+}
+
+// name returns the name of the output for messages: its path, or "stdout".
+func (o *outputWriter) name() string {
+	if o.path == "" || o.path == "-" {
+		return "stdout"
+	}
+	return o.path
+}
+
+// Write writes all of b to the output. Once a write fails, every later write fails with the same error. Errors are logged
+// before being returned, but only once.
+func (o *outputWriter) Write(b []byte) (int, error) {
+	if o.err != nil {
+		return 0, o.err
+	}
+	if o.err = writeAll(o.w, b); o.err != nil {
+		log.Printf("cannot write to %s: %v", o.name(), o.err)
+		return 0, o.err
+	}
+	return len(b), nil
+}
+
+// Close writes a trailing newline to the output if newline is true and no write has failed, then closes it. It returns the
+// first error writing to or closing the output. Errors are logged before being returned.
+func (o *outputWriter) Close(newline bool) error {
+	if newline && o.err == nil {
+		o.Write([]byte("\n"))
+	}
+
+	err := o.w.Close()
+	if err != nil {
+		log.Printf("cannot close %s: %v", o.name(), err)
+	}
+	if o.err != nil {
+		return o.err
+	}
+	return err
+}
+
+// writeOutputFile writes b to the file at path, followed by a newline if newline is true. Any missing parent directories of
+// path are created.
+func writeOutputFile(path string, b []byte, newline bool) (err error) {
+	if err = os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return err
+	}
+
+	fi, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := fi.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	if err = writeAll(fi, b); err == nil && newline {
+		_, err = io.WriteString(fi, "\n")
+	}
+	return err
+}
+
+// errStale is returned by checkOutput when an output file is missing or out of date.
+var errStale = errors.New("output is not up to date")
+
+// checkOutput compares b, the output that would be written to path, with the file at path. If the file doesn't exist or
+// differs, the difference is logged and errStale is returned. Errors are logged before being returned, with their exit
+// codes.
+func checkOutput(path string, b []byte) error {
+	have, err := ioutil.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		log.Printf("%s does not exist", path)
+		return withExitCode(exitStale, errStale)
+	case err != nil:
+		log.Printf("cannot read %s: %v", path, err)
+		return withExitCode(exitIO, err)
+	case bytes.Equal(have, b):
+		debugf("%s is up to date", path)
+		return nil
+	}
+	log.Printf("%s is out of date: %s", path, diffSummary(have, b))
+	return withExitCode(exitStale, errStale)
+}
+
+// maxDiffLine is the most of a line that diffSummary quotes.
+const maxDiffLine = 80
+
+// diffSummary describes how want, the output rendered, differs from have, the output file: the first line that differs in
+// each or, if one is a prefix of the other, how many bytes the file has too many or too few.
+func diffSummary(have, want []byte) string {
+	i := 0
+	for i < len(have) && i < len(want) && have[i] == want[i] {
+		i++
+	}
+	lineNum := bytes.Count(want[:i], []byte("\n")) + 1
+	switch {
+	case i == len(want) && i < len(have):
+		return fmt.Sprintf("it has %d more bytes than would be rendered, from line %d", len(have)-i, lineNum)
+	case i == len(have) && i < len(want):
+		return fmt.Sprintf("it is missing the last %d bytes that would be rendered, from line %d", len(want)-i, lineNum)
+	}
+
+	start := bytes.LastIndexByte(want[:i], '\n') + 1
+	line := func(b []byte) string {
+		b = b[start:]
+		if end := bytes.IndexByte(b, '\n'); end >= 0 {
+			b = b[:end]
+		}
+		if len(b) > maxDiffLine {
+			return fmt.Sprintf("%q...", b[:maxDiffLine])
+		}
+		return fmt.Sprintf("%q", b)
+	}
+	return fmt.Sprintf("line %d is %s but would be %s", lineNum, line(have), line(want))
+}
+
+// writeAll writes all of b to w, retrying short writes.
+func writeAll(w io.Writer, b []byte) error {
+	for len(b) > 0 {
+		n, err := w.Write(b)
+		if err != nil && err != io.ErrShortWrite {
+			return err
+		}
+		b = b[n:]
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nilium/resify/rtype"
+)
+
+func TestExpandOutputPattern(t *testing.T) {
+	table := []struct {
+		pattern, input, want string
+	}{
+		{"out/{{.Base}}.html", "resumes/jane.yaml", "out/jane.html"},
+		{"out/{{.Name}}.html", "resumes/jane.yaml", "out/jane.yaml.html"},
+		{"{{.Base}}.txt", "-", "stdin.txt"},
+		{"{{.Base}}", "no-extension", "no-extension"},
+		{"out/{{.Dir}}/{{.Base}}.html", "resumes/a/jane.yaml", "out/resumes/a/jane.html"},
+		{"out/{{.Dir}}/{{.Base}}.html", "jane.yaml", "out/./jane.html"},
+		{"{{.Dir}}/{{.Base}}.txt", "-", "./stdin.txt"},
+	}
+
+	for _, e := range table {
+		pattern, err := parseOutputPattern(e.pattern)
+		if err != nil || pattern == nil {
+			t.Errorf("cannot parse pattern %q: %v", e.pattern, err)
+			continue
+		}
+
+		if got, err := expandOutputPattern(pattern, e.input); err != nil || got != e.want {
+			t.Errorf("expanding %q for %q = %q, %v; expected %q", e.pattern, e.input, got, err, e.want)
+		}
+	}
+
+	if pattern, err := parseOutputPattern("out/plain.html"); pattern != nil || err != nil {
+		t.Errorf("expected plain path to not be a pattern; got %v, %v", pattern, err)
+	}
+}
+
+func TestCheckOutputPaths(t *testing.T) {
+	inputs := []string{"a/jane.yaml", "b/jane.yaml", "b/john.yaml"}
+	table := []struct {
+		pattern string
+		err     bool
+	}{
+		{"out/{{.Base}}.html", true},
+		{"out/{{.Dir}}/{{.Base}}.html", false},
+		{"out/{{.Name}}", true},
+		{"out/{{if eq .Dir \"a\"}}./{{end}}{{.Base}}.html", true},
+		{"out/{{.Missing}}.html", false},
+	}
+
+	for _, e := range table {
+		pattern, err := parseOutputPattern(e.pattern)
+		if err != nil {
+			t.Fatalf("cannot parse pattern %q: %v", e.pattern, err)
+		}
+		if err := checkOutputPaths(pattern, inputs); (err != nil) != e.err {
+			t.Errorf("checkOutputPaths(%q) = %v; want error: %t", e.pattern, err, e.err)
+		}
+	}
+}
+
+func TestWriteOutputFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a", "b", "out.txt")
+	if err := writeOutputFile(path, []byte("output"), true); err != nil {
+		t.Fatalf("unexpected error writing %s: %v", path, err)
+	}
+
+	if b, err := ioutil.ReadFile(path); err != nil || string(b) != "output\n" {
+		t.Errorf("expected %q in %s; got %q, %v", "output\n", path, b, err)
+	}
+}
+
+func TestOutputAction(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"exists.html": "old"})
+
+	if got := outputAction(filepath.Join(dir, "exists.html")); got != "overwrite" {
+		t.Errorf("outputAction(existing file) = %q; want %q", got, "overwrite")
+	}
+	if got := outputAction(filepath.Join(dir, "out", "new.html")); got != "create" {
+		t.Errorf("outputAction(missing file) = %q; want %q", got, "create")
+	}
+}
+
+// failingWriteCloser records what's written to it, failing writes after the first n bytes and failing to close if closeErr
+// is set.
+type failingWriteCloser struct {
+	bytes.Buffer
+	n        int
+	closeErr error
+	closed   bool
+}
+
+func (w *failingWriteCloser) Write(b []byte) (int, error) {
+	if w.Len()+len(b) > w.n {
+		return 0, errors.New("disk full")
+	}
+	return w.Buffer.Write(b)
+}
+
+func (w *failingWriteCloser) Close() error {
+	w.closed = true
+	return w.closeErr
+}
+
+func TestCheckOutput(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"resume.html": "<h1>Me</h1>\n<p>Engineer</p>\n"})
+	path := filepath.Join(dir, "resume.html")
+
+	table := []struct {
+		out  string
+		code int
+	}{
+		{"<h1>Me</h1>\n<p>Engineer</p>\n", exitOK},
+		{"<h1>Me</h1>\n<p>Senior Engineer</p>\n", exitStale},
+		{"<h1>Me</h1>\n<p>Engineer</p>", exitStale},
+		{"<h1>Me</h1>\n<p>Engineer</p>\n\n", exitStale},
+	}
+	for _, e := range table {
+		if got := exitCode(checkOutput(path, []byte(e.out))); got != e.code {
+			t.Errorf("checkOutput(%q) exit code = %d; want %d", e.out, got, e.code)
+		}
+	}
+
+	if got := exitCode(checkOutput(filepath.Join(dir, "missing.html"), nil)); got != exitStale {
+		t.Errorf("checkOutput(missing file) exit code = %d; want %d", got, exitStale)
+	}
+	if got := exitCode(checkOutput(dir, nil)); got != exitIO {
+		t.Errorf("checkOutput(directory) exit code = %d; want %d", got, exitIO)
+	}
+}
+
+func TestDiffSummary(t *testing.T) {
+	table := []struct {
+		have, want, summary string
+	}{
+		{"a\nb\nc\n", "a\nB\nc\n", `line 2 is "b" but would be "B"`},
+		{"a\nb\n", "a\nb\nc\n", "it is missing the last 2 bytes that would be rendered, from line 3"},
+		{"a\nb\n", "a\nb", "it has 1 more bytes than would be rendered, from line 2"},
+		{"", "a", "it is missing the last 1 bytes that would be rendered, from line 1"},
+	}
+	for _, e := range table {
+		if got := diffSummary([]byte(e.have), []byte(e.want)); got != e.summary {
+			t.Errorf("diffSummary(%q, %q) = %q; want %q", e.have, e.want, got, e.summary)
+		}
+	}
+}
+
+func TestOutputWriter(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	defer log.SetFlags(log.Flags())
+	log.SetFlags(0)
+
+	table := []struct {
+		path     string
+		n        int
+		closeErr error
+		newline  bool
+		want     string // What's written to the output.
+		logged   string
+	}{
+		{"out.txt", 100, nil, true, "resume\n", ""},
+		{"out.txt", 100, nil, false, "resume", ""},
+		{"out.txt", 100, errors.New("bad descriptor"), true, "resume\n", "cannot close out.txt: bad descriptor\n"},
+		{"-", 100, errors.New("bad descriptor"), true, "resume\n", "cannot close stdout: bad descriptor\n"},
+		{"out.txt", 3, nil, true, "", "cannot write to out.txt: disk full\n"},
+		{"out.txt", 6, nil, true, "resume", "cannot write to out.txt: disk full\n"},
+	}
+
+	for _, e := range table {
+		logs.Reset()
+		w := &failingWriteCloser{n: e.n, closeErr: e.closeErr}
+		o := &outputWriter{path: e.path, w: w}
+
+		o.Write([]byte("resume"))
+		err := o.Close(e.newline)
+		if (err == nil) != (e.logged == "") {
+			t.Errorf("Close(%t) on %s = %v; expected an error only if one was logged", e.newline, e.path, err)
+		}
+		if !w.closed {
+			t.Errorf("expected %s to be closed", e.path)
+		}
+		if w.String() != e.want {
+			t.Errorf("expected %q written to %s; got %q", e.want, e.path, w.String())
+		}
+		if logs.String() != e.logged {
+			t.Errorf("expected log %q; got %q", e.logged, logs.String())
+		}
+	}
+}
+
+func TestTrimOutputNoTrim(t *testing.T) {
+	dir := t.TempDir()
+	const src = "\n    indented block\n    {{ .Me.Chosen }}\n\n"
+	writeFiles(t, dir, map[string]string{"templates/index.tem": src})
+
+	defer func(d string) { dataDir = d }(dataDir)
+	dataDir = filepath.Join(dir, "templates")
+	defer func(v bool) { noTrim = v }(noTrim)
+
+	renderer, err := newRenderer(true, ".tem", "")
+	if err != nil {
+		t.Fatalf("unexpected error loading templates: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err = renderer.Render(&buf, rtype.Resume{Me: rtype.Me{Chosen: "Me"}}); err != nil {
+		t.Fatalf("unexpected error rendering: %v", err)
+	}
+
+	noTrim = false
+	if got, want := string(trimOutput(buf.Bytes())), "indented block\n    Me"; got != want {
+		t.Errorf("expected trimmed output %q; got %q", want, got)
+	}
+
+	noTrim = true
+	out := filepath.Join(dir, "out.txt")
+	if err = writeOutputFile(out, trimOutput(buf.Bytes()), false); err != nil {
+		t.Fatalf("unexpected error writing output: %v", err)
+	}
+	if b, _ := ioutil.ReadFile(out); string(b) != "\n    indented block\n    Me\n\n" {
+		t.Errorf("expected byte-exact output with -no-trim; got %q", b)
+	}
+}
@@ -0,0 +1,238 @@
+package render
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var (
+	// bundleTag matches the tags whose references Bundle inlines: a link, an image, or a script element, which may have a
+	// src attribute. It also matches comments and style elements, so that tags in them, or in the text of a script, are
+	// skipped over instead of being matched on their own.
+	bundleTag = regexp.MustCompile(`(?is)<!--.*?-->|<script\b[^>]*>.*?</script\s*>|<style\b[^>]*>.*?</style\s*>|<(?:link|img)\b[^>]*>`)
+
+	// htmlAttr matches an attribute of an HTML tag and its double-quoted, single-quoted, or unquoted value, if any.
+	htmlAttr = regexp.MustCompile(`(?s)([^\s"'>/=]+)(?:\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+)))?`)
+
+	// cssURL matches a url() reference in CSS and its optionally quoted URL.
+	cssURL = regexp.MustCompile(`url\(\s*(?:"([^"]*)"|'([^']*)'|([^'")\s]*))\s*\)`)
+
+	// closingTag matches the closing tags of style and script elements, which can't appear in files inlined into them.
+	closingTag = regexp.MustCompile(`(?i)</(?:style|script)`)
+)
+
+// attr is an attribute of an HTML tag, with its value unescaped.
+type attr struct {
+	name, value string
+	hasValue    bool
+}
+
+// parseTag returns the lower-case name and the attributes of the start tag, such as `<img src="me.jpg">`.
+func parseTag(tag string) (name string, attrs []attr) {
+	tag = strings.TrimSuffix(strings.TrimPrefix(tag, "<"), ">")
+	end := strings.IndexAny(tag, whitespace+"/>")
+	if end == -1 {
+		return strings.ToLower(tag), nil
+	}
+
+	for _, m := range htmlAttr.FindAllStringSubmatch(tag[end:], -1) {
+		attrs = append(attrs, attr{
+			name:     strings.ToLower(m[1]),
+			value:    html.UnescapeString(m[2] + m[3] + m[4]),
+			hasValue: strings.Contains(m[0], "="),
+		})
+	}
+	return strings.ToLower(tag[:end]), attrs
+}
+
+// formatTag returns a start tag for the element name with attrs, leaving out the attributes named by skip.
+func formatTag(name string, attrs []attr, skip ...string) string {
+	var b strings.Builder
+	b.WriteString("<" + name)
+next:
+	for _, a := range attrs {
+		for _, s := range skip {
+			if a.name == s {
+				continue next
+			}
+		}
+		b.WriteString(" " + a.name)
+		if a.hasValue {
+			b.WriteString(`="` + html.EscapeString(a.value) + `"`)
+		}
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// attrValue returns the value of the attribute called name in attrs, or an empty string if it has none.
+func attrValue(attrs []attr, name string) string {
+	for _, a := range attrs {
+		if a.name == name {
+			return a.value
+		}
+	}
+	return ""
+}
+
+// localPath returns the path of the file referred to by ref, a URL in a template's output, and whether it refers to a file
+// in the renderer's filesystem at all. URLs with a scheme or host, such as https: and data: URLs, and URLs without a path,
+// such as fragments, don't.
+func localPath(ref string) (string, bool) {
+	u, err := url.Parse(strings.Trim(ref, whitespace))
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Opaque != "" || u.Path == "" {
+		return "", false
+	}
+	return u.Path, true
+}
+
+// Bundle returns the HTML output b with the stylesheets, scripts, and images it refers to inlined, so that it can be read
+// without any other files: stylesheet links become style elements, scripts with a src attribute hold the script instead,
+// and image sources become data URIs (see the dataURI template function). Images, fonts, and other files referred to by
+// url() in inlined stylesheets are also inlined as data URIs, relative to the stylesheet. Only references to files in the
+// renderer's filesystem are inlined, with the same restrictions as the embed template function; absolute URLs are left as
+// they are. It's an error for a referenced file not to exist, or for an inlined stylesheet or script to contain a closing
+// style or script tag. Tags in comments, and text in script and style elements, are left as they are.
+func (r *Renderer) Bundle(b []byte) ([]byte, error) {
+	var err error
+	out := bundleTag.ReplaceAllStringFunc(string(b), func(tag string) string {
+		if err != nil {
+			return tag
+		}
+		var s string
+		if s, err = r.bundleTag(tag); err != nil {
+			return tag
+		}
+		return s
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []byte(out), nil
+}
+
+// bundleTag returns the tag matched by bundleTag with the file it refers to inlined, or the tag as it is if it doesn't
+// refer to a file in the renderer's filesystem. Comments, style elements, and scripts that aren't empty are returned as
+// they are.
+func (r *Renderer) bundleTag(tag string) (string, error) {
+	if strings.HasPrefix(tag, "<!--") {
+		return tag, nil
+	}
+
+	// Script elements are matched along with their text and closing tag.
+	start := tag
+	if i := strings.LastIndex(strings.ToLower(tag), "</script"); i >= 0 {
+		end := strings.Index(tag, ">") + 1
+		if strings.Trim(tag[end:i], whitespace) != "" {
+			return tag, nil
+		}
+		start = tag[:end]
+	}
+
+	name, attrs := parseTag(start)
+	switch name {
+	case "link":
+		rel := strings.Fields(strings.ToLower(attrValue(attrs, "rel")))
+		file, ok := localPath(attrValue(attrs, "href"))
+		if !ok || !containsString(rel, "stylesheet") {
+			return tag, nil
+		}
+		css, err := r.inlineStylesheet(file)
+		if err != nil {
+			return "", err
+		}
+		style := "<style>"
+		if media := attrValue(attrs, "media"); media != "" {
+			style = `<style media="` + html.EscapeString(media) + `">`
+		}
+		return style + css + "</style>", nil
+
+	case "script":
+		file, ok := localPath(attrValue(attrs, "src"))
+		if !ok {
+			return tag, nil
+		}
+		js, err := r.inlineFile(file)
+		if err != nil {
+			return "", err
+		}
+		return formatTag(name, attrs, "src", "integrity", "crossorigin", "async", "defer") + js + "</script>", nil
+
+	case "img":
+		file, ok := localPath(attrValue(attrs, "src"))
+		if !ok {
+			return tag, nil
+		}
+		uri, err := r.dataURI(file)
+		if err != nil {
+			return "", fmt.Errorf("bundle: cannot inline %s: %w", file, err)
+		}
+		for i := range attrs {
+			if attrs[i].name == "src" {
+				attrs[i].value = uri
+			}
+		}
+		return formatTag(name, attrs), nil
+	}
+	return tag, nil
+}
+
+// inlineFile returns the contents of the file at name to be written inside a style or script element.
+func (r *Renderer) inlineFile(name string) (string, error) {
+	s, err := r.readFile(name)
+	if err != nil {
+		return "", fmt.Errorf("bundle: cannot inline %s: %w", name, err)
+	}
+	if closingTag.MatchString(s) {
+		return "", fmt.Errorf("bundle: cannot inline %s: it contains a closing style or script tag", name)
+	}
+	return s, nil
+}
+
+// inlineStylesheet returns the contents of the stylesheet at name with the files its url() references refer to inlined
+// as data URIs. References are relative to the stylesheet, or to the root of the renderer's filesystem if they begin with
+// a slash.
+func (r *Renderer) inlineStylesheet(name string) (string, error) {
+	css, err := r.inlineFile(name)
+	if err != nil {
+		return "", err
+	}
+
+	css = cssURL.ReplaceAllStringFunc(css, func(ref string) string {
+		if err != nil {
+			return ref
+		}
+		m := cssURL.FindStringSubmatch(ref)
+		file, ok := localPath(m[1] + m[2] + m[3])
+		if !ok {
+			return ref
+		}
+		if !strings.HasPrefix(file, "/") {
+			file = path.Join(path.Dir("/"+strings.TrimLeft(name, "/")), file)
+		}
+
+		var uri string
+		if uri, err = r.dataURI(file); err != nil {
+			err = fmt.Errorf("bundle: cannot inline %s from %s: %w", file, name, err)
+			return ref
+		}
+		return `url("` + uri + `")`
+	})
+	if err != nil {
+		return "", err
+	}
+	return css, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
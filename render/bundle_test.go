@@ -0,0 +1,92 @@
+package render
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestBundle(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"index.tem":          `unused`,
+		"css/style.css":      `@font-face { src: url("../fonts/body.woff2"); } h1 { background: url(#grad) }`,
+		"css/print.css":      `body { background: url('https://example.com/bg.png') }`,
+		"fonts/body.woff2":   "wOF2",
+		"me.png":             "\x89PNG\r\n\x1a\n",
+		"js/app.js":          `console.log("a < b");`,
+		"bad.js":             `document.write("</script>");`,
+		"css/escape.css":     `body { background: url("../../../secret.png") }`,
+		"css/missing-in.css": `body { background: url(missing.png) }`,
+	})
+	r, err := New(fsys, Options{Ext: ".tem"})
+	if err != nil {
+		t.Fatalf("unexpected error loading templates: %v", err)
+	}
+
+	table := []struct {
+		in, want string
+	}{
+		{
+			`<link rel="stylesheet" href="css/style.css">`,
+			`<style>@font-face { src: url("data:font/woff2;base64,d09GMg=="); } h1 { background: url(#grad) }</style>`,
+		},
+		{
+			`<LINK REL='preload stylesheet' media="print" href=/css/print.css>`,
+			`<style media="print">body { background: url('https://example.com/bg.png') }</style>`,
+		},
+		{`<link rel="icon" href="me.png">`, `<link rel="icon" href="me.png">`},
+		{`<link rel="stylesheet" href="https://example.com/style.css">`, `<link rel="stylesheet" href="https://example.com/style.css">`},
+		{
+			`<script defer src="js/app.js?v=2" type="module"></script>`,
+			`<script type="module">console.log("a < b");</script>`,
+		},
+		{`<script>inline()</script>`, `<script>inline()</script>`},
+		{`<script src="//example.com/app.js"></script>`, `<script src="//example.com/app.js"></script>`},
+		{
+			`<img class="photo" src="me.png" alt="Me &amp; you">`,
+			`<img class="photo" src="data:image/png;base64,iVBORw0KGgo=" alt="Me &amp; you">`,
+		},
+		{`<img src="data:image/gif;base64,R0lGOD" alt="">`, `<img src="data:image/gif;base64,R0lGOD" alt="">`},
+		{`<p>No references</p>`, `<p>No references</p>`},
+		// Tags in comments and in the text of scripts and stylesheets aren't references.
+		{`<!-- <img src="missing.png"> -->`, `<!-- <img src="missing.png"> -->`},
+		{
+			`<!--<link rel="stylesheet" href="missing.css">--><img src="me.png">`,
+			`<!--<link rel="stylesheet" href="missing.css">--><img src="data:image/png;base64,iVBORw0KGgo=">`,
+		},
+		{
+			`<script>document.write('<img src="missing.png">')</script>`,
+			`<script>document.write('<img src="missing.png">')</script>`,
+		},
+		{`<style>/* <img src="missing.png"> */</style>`, `<style>/* <img src="missing.png"> */</style>`},
+	}
+
+	for _, e := range table {
+		got, err := r.Bundle([]byte(e.in))
+		if err != nil {
+			t.Errorf("unexpected error bundling %q: %v", e.in, err)
+		} else if string(got) != e.want {
+			t.Errorf("Bundle(%q) = %q; want %q", e.in, got, e.want)
+		}
+	}
+
+	for _, in := range []string{
+		`<img src="missing.png">`,
+		`<img src="../secret.png">`,
+		`<script src="bad.js"></script>`,
+		`<link rel="stylesheet" href="css/missing-in.css">`,
+	} {
+		if got, err := r.Bundle([]byte(in)); err == nil {
+			t.Errorf("expected error bundling %q; got %q", in, got)
+		}
+	}
+
+	// References in stylesheets can't leave the filesystem either; they stop at its root.
+	if _, err := r.Bundle([]byte(`<link rel="stylesheet" href="css/escape.css">`)); err == nil ||
+		!strings.Contains(err.Error(), "/secret.png") {
+		t.Errorf("expected error bundling a stylesheet referring outside the filesystem; got %v", err)
+	}
+	if _, err := r.Bundle([]byte(`<img src="../secret.png">`)); !errors.Is(err, ErrEscapeAttempt) {
+		t.Errorf("expected ErrEscapeAttempt; got %v", err)
+	}
+}
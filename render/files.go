@@ -0,0 +1,251 @@
+package render
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	textt "text/template"
+
+	"github.com/nilium/resify/rtype"
+)
+
+// ErrEscapeAttempt is returned by the embed and dataURI template functions when given a path that refers to something
+// outside of the templates filesystem.
+var ErrEscapeAttempt = errors.New("attempt to leave data directory via embed")
+
+// MissingEmbedError is returned by Execute and Render when a template embeds a file that doesn't exist, unless
+// Options.IgnoreMissingEmbeds is set.
+type MissingEmbedError struct {
+	// Template is the name of the template that called embed, followed by the line and column of the call if known (e.g.,
+	// "index.tem:3:16"). It's empty if the error wasn't returned by Execute.
+	Template string
+
+	// Path is the path given to embed.
+	Path string
+
+	// Err is the error returned by the templates filesystem.
+	Err error
+}
+
+func (e *MissingEmbedError) Error() string {
+	msg := fmt.Sprintf("embed %q: no such file in the templates directory", e.Path)
+	if e.Template != "" {
+		msg = e.Template + ": " + msg
+	}
+	return msg
+}
+
+func (e *MissingEmbedError) Unwrap() error { return e.Err }
+
+// execLocation matches the template name, line, and column at the start of an error executing a template.
+var execLocation = regexp.MustCompile(`^template: (.+?:[0-9]+(?::[0-9]+)?): `)
+
+// missingEmbed returns the MissingEmbedError in err, an error executing a template, with the template and location that
+// called embed filled in. If err doesn't hold a MissingEmbedError, it returns nil.
+func missingEmbed(err error) *MissingEmbedError {
+	var missing *MissingEmbedError
+	if !errors.As(err, &missing) {
+		return nil
+	}
+
+	e := *missing
+	var exec textt.ExecError
+	if errors.As(err, &exec) {
+		e.Template = exec.Name
+		if m := execLocation.FindStringSubmatch(exec.Error()); m != nil {
+			e.Template = m[1]
+		}
+	}
+	return &e
+}
+
+// escapesDir returns whether the cleaned, relative path refers to something outside of the directory it's relative to.
+func escapesDir(path string) bool {
+	return path == ".." || strings.HasPrefix(path, "../")
+}
+
+// fileCache is a concurrency-safe cache of file contents.
+type fileCache struct {
+	mu    sync.Mutex
+	files map[string]*cachedFile
+}
+
+// cachedFile is a file in a fileCache, which may still be being read.
+type cachedFile struct {
+	done chan struct{} // Closed once the file has been read.
+	b    []byte
+	err  error
+}
+
+// get returns the contents cached under key. If there are none, read is called and its result is cached, unless it
+// returns an error. Files are read without holding the cache's lock, so reading one file doesn't hold up others, but
+// callers getting a key that's being read wait for that read and share its result. The returned slice is shared and must
+// not be modified.
+func (c *fileCache) get(key string, read func() ([]byte, error)) ([]byte, error) {
+	c.mu.Lock()
+	f, ok := c.files[key]
+	if !ok {
+		f = &cachedFile{done: make(chan struct{})}
+		if c.files == nil {
+			c.files = map[string]*cachedFile{}
+		}
+		c.files[key] = f
+	}
+	c.mu.Unlock()
+
+	if ok {
+		<-f.done
+		return f.b, f.err
+	}
+
+	f.b, f.err = read()
+	if f.err != nil {
+		c.mu.Lock()
+		delete(c.files, key)
+		c.mu.Unlock()
+	}
+	close(f.done)
+	return f.b, f.err
+}
+
+// dataPath returns the path of name in a filesystem (see fs.ValidPath). Leading slashes are ignored, so "/style.css" and
+// "style.css" are the same file. Paths that refer to something outside of the filesystem are rejected with
+// ErrEscapeAttempt.
+func dataPath(name string) (string, error) {
+	name = path.Clean(filepath.ToSlash(name))
+	if escapesDir(name) {
+		return "", ErrEscapeAttempt
+	}
+	name = strings.TrimLeft(name, "/")
+	if name == "" {
+		name = "."
+	}
+	return name, nil
+}
+
+// readDataFile returns the contents of the file at name in the renderer's filesystem. Each file is only read once by a
+// renderer, so the returned slice must not be modified.
+func (r *Renderer) readDataFile(name string) ([]byte, error) {
+	name, err := dataPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.files.get(name, func() ([]byte, error) {
+		r.debugf("embedding %s", name)
+		return fs.ReadFile(r.fsys, name)
+	})
+}
+
+// readFile opens the file at path and returns its contents as a string. If any error occurs, that error is returned with an
+// empty string.
+func (r *Renderer) readFile(path string) (string, error) {
+	b, err := r.readDataFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// embed returns the contents of the file at path for the embed template function. If the file doesn't exist, it returns
+// a MissingEmbedError or, if the renderer ignores missing embeds, logs a warning and returns an empty string.
+func (r *Renderer) embed(path string) (string, error) {
+	s, err := r.readFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		if r.ignoreMissingEmbeds {
+			r.warnf("embed %q: no such file in the templates directory, so embedding nothing", path)
+			return "", nil
+		}
+		return "", &MissingEmbedError{Path: path, Err: err}
+	}
+	return s, err
+}
+
+// dataURI opens the file at name and returns its contents as a base64 data URI. The MIME type of the file is determined by
+// its extension or, if the extension isn't known, by sniffing its contents.
+func (r *Renderer) dataURI(name string) (string, error) {
+	b, err := r.readDataFile(name)
+	if err != nil {
+		return "", err
+	}
+
+	typ := mime.TypeByExtension(path.Ext(name))
+	if typ == "" {
+		typ = http.DetectContentType(b)
+	}
+	typ = strings.Replace(typ, " ", "", -1)
+
+	return "data:" + typ + ";base64," + base64.StdEncoding.EncodeToString(b), nil
+}
+
+// srcsetDescriptor matches the width (320w) and pixel density (2x) descriptors of a srcset image candidate.
+var srcsetDescriptor = regexp.MustCompile(`^(?:[0-9]+w|[0-9]+(?:\.[0-9]+)?x)$`)
+
+// srcset returns a srcset attribute value for the images given, each the path of an image in the renderer's filesystem
+// optionally followed by a width or pixel density descriptor, as in "me-320.jpg 320w". Anything else following the path is
+// part of it. If inline is true, each image is inlined as a data URI (see dataURI). Otherwise, each image's path is used as
+// a relative URL, and the image only has to exist. Paths that refer to something outside of the filesystem are rejected
+// with ErrEscapeAttempt.
+func (r *Renderer) srcset(inline bool, images []string) (string, error) {
+	if len(images) == 0 {
+		return "", errors.New("srcset: no images given")
+	}
+
+	candidates := make([]string, 0, len(images))
+	for _, image := range images {
+		name, descriptor := strings.Trim(image, whitespace), ""
+		if i := strings.LastIndexAny(name, whitespace); i >= 0 && srcsetDescriptor.MatchString(name[i+1:]) {
+			name, descriptor = strings.TrimRight(name[:i], whitespace), name[i+1:]
+		}
+		if name == "" {
+			return "", fmt.Errorf("srcset: invalid image %q; must be a path and an optional descriptor", image)
+		}
+
+		candidate := ""
+		if inline {
+			uri, err := r.dataURI(name)
+			if err != nil {
+				return "", err
+			}
+			candidate = uri
+		} else {
+			clean, err := dataPath(name)
+			if err != nil {
+				return "", err
+			}
+			if _, err = fs.Stat(r.fsys, clean); err != nil {
+				return "", err
+			}
+			// Commas separate image candidates, so they can't appear unescaped in a URL.
+			candidate = strings.Replace((&url.URL{Path: clean}).EscapedPath(), ",", "%2C", -1)
+		}
+
+		if descriptor != "" {
+			candidate += " " + descriptor
+		}
+		candidates = append(candidates, candidate)
+	}
+	return strings.Join(candidates, ", "), nil
+}
+
+// photo returns a URL for the photo at src, which is either an absolute http(s) URL, returned as is, or the path of an image
+// in the renderer's filesystem, returned as a data URI (see dataURI). An empty src gives an empty URL.
+func (r *Renderer) photo(src string) (string, error) {
+	switch {
+	case src == "":
+		return "", nil
+	case (rtype.Me{Photo: src}).PhotoIsURL():
+		return src, nil
+	default:
+		return r.dataURI(src)
+	}
+}
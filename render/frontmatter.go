@@ -0,0 +1,55 @@
+package render
+
+import (
+	"strings"
+
+	"github.com/nilium/resify/rtype"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// frontMatterFence is the line that begins and ends a template's front matter.
+const frontMatterFence = "---"
+
+// FrontMatter is metadata declared by a template in a block of YAML at its start, fenced by "---" lines:
+//
+//  ---
+//  output: resume.html
+//  contentType: text/html; charset=utf-8
+//  ---
+//  <!DOCTYPE html>
+//
+// The block is removed from the template before it's parsed, so it doesn't appear in its output.
+type FrontMatter struct {
+	Output      string `yaml:"output,omitempty"`      // A path to write the template's output to.
+	ContentType string `yaml:"contentType,omitempty"` // The media type of the template's output.
+
+	// Meta holds any other keys in the front matter.
+	Meta rtype.Meta `yaml:",inline"`
+}
+
+// splitFrontMatter returns the front matter at the start of the template source src, if it has any, and the rest of src. If
+// src doesn't begin with a "---" line, or there's no "---" line to end the front matter, src has no front matter and is
+// returned unchanged.
+func splitFrontMatter(src string) (front FrontMatter, body string, err error) {
+	start := strings.IndexByte(src, '\n') + 1
+	if start == 0 || strings.TrimRight(src[:start-1], "\r") != frontMatterFence {
+		return FrontMatter{}, src, nil
+	}
+
+	for i := start; i < len(src); {
+		line, next := src[i:], len(src)
+		if end := strings.IndexByte(line, '\n'); end >= 0 {
+			line, next = line[:end], i+end+1
+		}
+
+		if strings.TrimRight(line, "\r") == frontMatterFence {
+			if err = yaml.Unmarshal([]byte(src[start:i]), &front); err != nil {
+				return FrontMatter{}, src, err
+			}
+			return front, src[next:], nil
+		}
+		i = next
+	}
+
+	return FrontMatter{}, src, nil
+}
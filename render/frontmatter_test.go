@@ -0,0 +1,84 @@
+package render
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nilium/resify/rtype"
+)
+
+func TestSplitFrontMatter(t *testing.T) {
+	table := []struct {
+		src   string
+		front FrontMatter
+		body  string
+		ok    bool
+	}{
+		{"no front matter", FrontMatter{}, "no front matter", true},
+		{"---\noutput: out.html\ncontentType: text/html\n---\n<p>body</p>", FrontMatter{Output: "out.html", ContentType: "text/html"}, "<p>body</p>", true},
+		{"---\r\noutput: out.txt\r\n---\r\nbody\r\n", FrontMatter{Output: "out.txt"}, "body\r\n", true},
+		{"---\ntheme: dark\n---\n", FrontMatter{Meta: rtype.Meta{"theme": "dark"}}, "", true},
+		{"---\n---\nbody", FrontMatter{}, "body", true},
+		{"---\noutput: out.html\n---", FrontMatter{Output: "out.html"}, "", true},
+		{"---\nnot: closed\nbody", FrontMatter{}, "---\nnot: closed\nbody", true},
+		{"--- \nbody\n---\n", FrontMatter{}, "--- \nbody\n---\n", true},
+		{"body\n---\noutput: x\n---\n", FrontMatter{}, "body\n---\noutput: x\n---\n", true},
+		{"---\noutput: [\n---\nbody", FrontMatter{}, "", false},
+	}
+
+	for _, e := range table {
+		front, body, err := splitFrontMatter(e.src)
+		if (err == nil) != e.ok {
+			t.Errorf("splitFrontMatter(%q) error = %v; expected ok: %v", e.src, err, e.ok)
+			continue
+		}
+		if !e.ok {
+			continue
+		}
+		if !reflect.DeepEqual(front, e.front) || body != e.body {
+			t.Errorf("splitFrontMatter(%q) = %+v, %q; expected %+v, %q", e.src, front, body, e.front, e.body)
+		}
+	}
+}
+
+func TestRendererFrontMatter(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"index.tem":  "---\noutput: resume.txt\n---\n{{ template \"part.tem\" . }}",
+		"part.tem":   "---\noutput: ignored.txt\n---\n{{ .Me.Chosen }}",
+		"plain.tem":  "plain",
+		"define.tem": `{{ define "defined" }}defined{{ end }}`,
+	})
+
+	table := []struct {
+		opts   Options
+		output string
+		body   string
+	}{
+		{Options{Text: true, Ext: ".tem"}, "resume.txt", "Jane"},
+		{Options{Text: true, Ext: ".tem", Template: "plain"}, "", "plain"},
+		{Options{Text: true, Ext: ".tem", Template: "defined"}, "", "defined"},
+		{Options{Text: true, Ext: ".tem", TemplateSource: "---\noutput: main.txt\n---\n{{ .Me.Chosen }}!"}, "main.txt", "Jane!"},
+	}
+
+	var resume rtype.Resume
+	resume.Me.Chosen = "Jane"
+
+	for _, e := range table {
+		r, err := New(fsys, e.opts)
+		if err != nil {
+			t.Errorf("unexpected error loading templates: %v", err)
+			continue
+		}
+		if got := r.FrontMatter().Output; got != e.output {
+			t.Errorf("FrontMatter().Output = %q for %s; expected %q", got, r.Name(), e.output)
+		}
+
+		var buf strings.Builder
+		if err = r.Render(&buf, resume); err != nil {
+			t.Errorf("unexpected error rendering %s: %v", r.Name(), err)
+		} else if buf.String() != e.body {
+			t.Errorf("rendered %s as %q; expected %q", r.Name(), buf.String(), e.body)
+		}
+	}
+}
@@ -0,0 +1,581 @@
+package render
+
+import (
+	"fmt"
+	htmlt "html/template"
+	"math"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	textt "text/template"
+	"time"
+	"unicode"
+
+	"github.com/nilium/resify/linkify"
+	"github.com/nilium/resify/rtype"
+)
+
+// textFuncs are the functions available to text templates, other than those bound to a Renderer (see Renderer.textFuncs).
+var textFuncs = textt.FuncMap{
+	"html":        nopstring,
+	"attr":        nopstring,
+	"css":         nopstring,
+	"js":          nopstring,
+	"url":         nopstring,
+	"escape":      nopstring,
+	"markdown":    markdownText,
+	"date":        formatDate,
+	"year":        formatYear,
+	"sortByDate":  sortByDate,
+	"filterMeta":  filterMeta,
+	"metaStr":     metaStr,
+	"metaBool":    metaBool,
+	"metaInt":     metaInt,
+	"phone":       formatPhone,
+	"telURI":      func(s string) string { uri, _ := telURI(s); return uri },
+	"obfuscate":   nopstring,
+	"mailto":      nopstring,
+	"words":       countWords,
+	"readingTime": readingTime,
+	"slug":        slug,
+
+	"totalExperience": totalExperience,
+	"groupByEmployer": groupByEmployer,
+	"sections":        sections,
+	"contactLinks":    contactLinks,
+}
+
+// markdownFuncs replace textFuncs in text templates producing Markdown.
+var markdownFuncs = textt.FuncMap{
+	"escape":   markdownEscape,
+	"markdown": markdownSource,
+}
+
+// htmlFuncs are the functions available to HTML templates, other than those bound to a Renderer (see Renderer.htmlFuncs).
+// Functions that return markup or URLs return them as the html/template types for their contexts, so that they aren't
+// escaped.
+var htmlFuncs = htmlt.FuncMap{
+	"html":        func(s string) htmlt.HTML { return htmlt.HTML(s) },
+	"attr":        func(s string) htmlt.HTMLAttr { return htmlt.HTMLAttr(s) },
+	"css":         func(s string) htmlt.CSS { return htmlt.CSS(s) },
+	"js":          func(s string) htmlt.JS { return htmlt.JS(s) },
+	"url":         func(s string) htmlt.URL { return htmlt.URL(s) },
+	"escape":      nopstring,
+	"markdown":    markdownHTML,
+	"date":        formatDate,
+	"year":        formatYear,
+	"sortByDate":  sortByDate,
+	"filterMeta":  filterMeta,
+	"metaStr":     metaStr,
+	"metaBool":    metaBool,
+	"metaInt":     metaInt,
+	"phone":       formatPhone,
+	"telURI":      htmlTelURI,
+	"obfuscate":   func(s string) htmlt.HTML { return htmlt.HTML(obfuscate(s)) },
+	"mailto":      func(s string) htmlt.HTML { return htmlt.HTML(obfuscateMailto(s)) },
+	"words":       countWords,
+	"readingTime": readingTime,
+	"slug":        slug,
+
+	"totalExperience": totalExperience,
+	"groupByEmployer": groupByEmployer,
+	"sections":        sections,
+	"contactLinks":    htmlContactLinks,
+}
+
+// formatDate formats t, which must be a time.Time or rtype.DateRange, using layout. Zero times are formatted as an empty
+// string. A date range is formatted as its non-zero ends joined by " - ".
+func formatDate(layout string, t interface{}) (string, error) {
+	switch t := t.(type) {
+	case time.Time:
+		if t.IsZero() {
+			return "", nil
+		}
+		return t.Format(layout), nil
+	case rtype.DateRange:
+		return t.Format(layout), nil
+	default:
+		return "", fmt.Errorf("cannot format %T as a date", t)
+	}
+}
+
+// formatYear returns the four-digit year of t, which must be a time.Time or rtype.DateRange. It's the same as formatDate with
+// the layout "2006".
+func formatYear(t interface{}) (string, error) {
+	return formatDate("2006", t)
+}
+
+// sortByDate returns a copy of entries, which must be a slice of structs with a When or Date field of type rtype.DateRange
+// (such as .Employment, .Education, .Awards, or .Publications), sorted by the start of that range. The direction is either
+// "asc" (oldest first) or "desc" (newest first). Entries with the same start are ordered by their end, with ongoing ranges
+// (those without an end) counted as ending last. Otherwise, entries keep their original order.
+func sortByDate(entries interface{}, direction string) (interface{}, error) {
+	var desc bool
+	switch direction {
+	case "asc":
+	case "desc":
+		desc = true
+	default:
+		return nil, fmt.Errorf("unrecognized sort direction %q: must be asc or desc", direction)
+	}
+
+	v := reflect.ValueOf(entries)
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("cannot sort %T by date: not a slice", entries)
+	}
+
+	ranges := make([]rtype.DateRange, v.Len())
+	idx := make([]int, v.Len())
+	for i := range idx {
+		when, ok := structField(v.Index(i), "When").Interface().(rtype.DateRange)
+		if !ok {
+			when, ok = structField(v.Index(i), "Date").Interface().(rtype.DateRange)
+		}
+		if !ok {
+			return nil, fmt.Errorf("cannot sort %T by date: element has no When or Date date range", entries)
+		}
+		ranges[i], idx[i] = when, i
+	}
+
+	// before returns whether a ends before b. Zero (ongoing) ends are treated as later than any other end.
+	before := func(a, b time.Time) bool {
+		if a.IsZero() || b.IsZero() {
+			return !a.IsZero() && b.IsZero()
+		}
+		return a.Before(b)
+	}
+
+	sort.SliceStable(idx, func(i, j int) bool {
+		a, b := ranges[idx[i]], ranges[idx[j]]
+		if desc {
+			a, b = b, a
+		}
+		if !a.From.Equal(b.From) {
+			return a.From.Before(b.From)
+		}
+		return before(a.To, b.To)
+	})
+
+	sorted := reflect.MakeSlice(v.Type(), 0, v.Len())
+	for _, i := range idx {
+		sorted = reflect.Append(sorted, v.Index(i))
+	}
+	return sorted.Interface(), nil
+}
+
+// filterMeta returns the entries of a slice of structs with a Meta field (such as .Employment) whose metadata value for key
+// is true, by the same rules as the template if action. If want is given and false, it instead returns the entries whose
+// value is false, which includes those without the key:
+//
+//  {{ range filterMeta .Employment "hidden" false }}...{{ end }}
+func filterMeta(entries interface{}, key string, want ...bool) (interface{}, error) {
+	keep := true
+	switch len(want) {
+	case 0:
+	case 1:
+		keep = want[0]
+	default:
+		return nil, fmt.Errorf("filterMeta takes at most one boolean; got %d", len(want))
+	}
+
+	v := reflect.ValueOf(entries)
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("cannot filter %T by metadata: not a slice", entries)
+	}
+
+	filtered := reflect.MakeSlice(v.Type(), 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		meta, ok := structField(v.Index(i), "Meta").Interface().(rtype.Meta)
+		if !ok {
+			return nil, fmt.Errorf("cannot filter %T by metadata: element has no Meta", entries)
+		}
+
+		truth, _ := textt.IsTrue(meta[key])
+		if truth == keep {
+			filtered = reflect.Append(filtered, v.Index(i))
+		}
+	}
+	return filtered.Interface(), nil
+}
+
+// structField returns the named field of the struct (or pointer to a struct) v. If v isn't a struct or has no such field,
+// the zero Value for an interface{} is returned, which yields nil from Interface.
+func structField(v reflect.Value, name string) reflect.Value {
+	v = reflect.Indirect(v)
+	if v.Kind() == reflect.Struct {
+		if f := v.FieldByName(name); f.IsValid() {
+			return f
+		}
+	}
+	return reflect.Zero(reflect.TypeOf((*interface{})(nil)).Elem())
+}
+
+// phoneDigits returns the number in phone with formatting (spaces, dashes, dots, and parentheses) removed, keeping a leading
+// plus sign. If phone has anything else in it, or has fewer than 7 or more than 15 digits, ok is false.
+func phoneDigits(phone string) (number string, ok bool) {
+	phone = strings.TrimSpace(phone)
+	digits := make([]byte, 0, len(phone))
+	for i := 0; i < len(phone); i++ {
+		switch c := phone[i]; {
+		case c >= '0' && c <= '9':
+			digits = append(digits, c)
+		case c == '+' && i == 0:
+		case c == ' ', c == '-', c == '.', c == '(', c == ')':
+		default:
+			return "", false
+		}
+	}
+
+	if len(digits) < 7 || len(digits) > 15 {
+		return "", false
+	}
+	if strings.HasPrefix(phone, "+") {
+		return "+" + string(digits), true
+	}
+	return string(digits), true
+}
+
+// formatPhone returns phone in a readable form. North American numbers, either +1 followed by ten digits or ten digits
+// alone, are formatted as "+1 (234) 567-8901" or "(234) 567-8901". Other numbers, since their grouping varies by country,
+// and numbers that can't be parsed are returned unchanged.
+func formatPhone(phone string) string {
+	number, ok := phoneDigits(phone)
+	if !ok {
+		return phone
+	}
+
+	nanp := func(n string) string {
+		return "(" + n[:3] + ") " + n[3:6] + "-" + n[6:]
+	}
+	switch {
+	case len(number) == 12 && strings.HasPrefix(number, "+1"):
+		return "+1 " + nanp(number[2:])
+	case len(number) == 10:
+		return nanp(number)
+	default:
+		return phone
+	}
+}
+
+// telURI returns phone as a tel: URI, as in "tel:+12345678901". If phone can't be parsed, ok is false and phone is returned
+// unchanged.
+func telURI(phone string) (uri string, ok bool) {
+	number, ok := phoneDigits(phone)
+	if !ok {
+		return phone, false
+	}
+	return "tel:" + number, true
+}
+
+// obfuscate returns s with every character encoded as a hexadecimal HTML character reference (e.g., "&#x61;" for "a"), for
+// email addresses that scrapers won't find in the page source but that browsers still display as normal text.
+func obfuscate(s string) string {
+	var buf strings.Builder
+	for _, r := range s {
+		fmt.Fprintf(&buf, "&#x%x;", r)
+	}
+	return buf.String()
+}
+
+// obfuscateMailto returns a mailto: link to the email address, with both the link and its text obfuscated (see obfuscate).
+func obfuscateMailto(email string) string {
+	return `<a href="` + obfuscate("mailto:"+email) + `">` + obfuscate(email) + `</a>`
+}
+
+// metaValue returns the value of key in meta, which is either metadata or a map of strings, such as .Extra. If key is
+// missing or its value is nil, ok is false.
+func metaValue(meta interface{}, key string) (v interface{}, ok bool, err error) {
+	switch meta := meta.(type) {
+	case rtype.Meta:
+		v, ok = meta[key]
+	case map[string]interface{}:
+		v, ok = meta[key]
+	case map[string]string:
+		v, ok = meta[key]
+	case nil:
+	default:
+		return nil, false, fmt.Errorf("cannot get %q from %T: not metadata or a map of strings", key, meta)
+	}
+	return v, ok && v != nil, nil
+}
+
+// metaStr returns the value of key in meta as a string. Values that aren't strings are formatted as by fmt.Sprint. If key is
+// missing, the default is returned if given, or an empty string otherwise.
+func metaStr(meta interface{}, key string, def ...string) (string, error) {
+	if v, ok, err := metaValue(meta, key); err != nil {
+		return "", err
+	} else if ok {
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+		return fmt.Sprint(v), nil
+	}
+
+	if len(def) > 1 {
+		return "", fmt.Errorf("metaStr takes at most one default; got %d", len(def))
+	} else if len(def) == 1 {
+		return def[0], nil
+	}
+	return "", nil
+}
+
+// metaBool returns the value of key in meta as a bool. Strings are parsed by strconv.ParseBool. If key is missing or its
+// value isn't a bool, the default is returned if given, or false otherwise.
+func metaBool(meta interface{}, key string, def ...bool) (bool, error) {
+	if v, ok, err := metaValue(meta, key); err != nil {
+		return false, err
+	} else if ok {
+		switch v := v.(type) {
+		case bool:
+			return v, nil
+		case string:
+			if b, err := strconv.ParseBool(v); err == nil {
+				return b, nil
+			}
+		}
+	}
+
+	if len(def) > 1 {
+		return false, fmt.Errorf("metaBool takes at most one default; got %d", len(def))
+	} else if len(def) == 1 {
+		return def[0], nil
+	}
+	return false, nil
+}
+
+// metaInt returns the value of key in meta as an int. Whole floats and strings of integers are converted. If key is missing
+// or its value isn't an integer, the default is returned if given, or 0 otherwise.
+func metaInt(meta interface{}, key string, def ...int) (int, error) {
+	if v, ok, err := metaValue(meta, key); err != nil {
+		return 0, err
+	} else if ok {
+		switch v := v.(type) {
+		case int:
+			return v, nil
+		case int64:
+			if int64(int(v)) == v {
+				return int(v), nil
+			}
+		case uint64:
+			if n := int(v); n >= 0 && uint64(n) == v {
+				return int(v), nil
+			}
+		case float64:
+			if v == math.Trunc(v) && v >= math.MinInt32 && v <= math.MaxInt32 {
+				return int(v), nil
+			}
+		case string:
+			if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+				return n, nil
+			}
+		}
+	}
+
+	if len(def) > 1 {
+		return 0, fmt.Errorf("metaInt takes at most one default; got %d", len(def))
+	} else if len(def) == 1 {
+		return def[0], nil
+	}
+	return 0, nil
+}
+
+// htmlTelURI returns the tel: URI for phone as a URL that's safe in HTML templates. If phone can't be parsed, it's returned
+// unchanged as a string, to be escaped or filtered as usual.
+func htmlTelURI(phone string) interface{} {
+	if uri, ok := telURI(phone); ok {
+		return htmlt.URL(uri)
+	}
+	return phone
+}
+
+// wordsPerMinute is the reading speed assumed by readingTime.
+const wordsPerMinute = 200
+
+// countWords returns the number of words in s, separated by any run of whitespace. Links are counted by their labels (see
+// linkify.Labels), so s should be text given to linkify rather than its result.
+func countWords(s string) int {
+	return len(strings.Fields(linkify.Labels(s)))
+}
+
+// readingTime returns the estimated number of minutes needed to read s at wordsPerMinute, rounded up. Text with no words
+// takes no time.
+func readingTime(s string) int {
+	return (countWords(s) + wordsPerMinute - 1) / wordsPerMinute
+}
+
+// totalExperience returns the combined length of the employment entries in work, without counting overlapping time more
+// than once (see rtype.TotalDuration).
+func totalExperience(work []rtype.Employment) rtype.Span {
+	ranges := make([]rtype.DateRange, len(work))
+	for i, e := range work {
+		ranges[i] = e.When
+	}
+	return rtype.TotalDuration(ranges)
+}
+
+// EmployerGroup is the employment entries for one employer, as returned by groupByEmployer.
+type EmployerGroup struct {
+	Employer rtype.Place        // The employer, as given by the first of its roles.
+	Roles    []rtype.Employment // The employer's roles, in the order given.
+}
+
+// groupByEmployer groups the employment entries in work by their employer's name, in the order each employer first
+// appears. Entries for the same employer are grouped together even if other employers come between them. Entries without
+// an employer name are each given a group of their own.
+func groupByEmployer(work []rtype.Employment) []EmployerGroup {
+	groups := make([]EmployerGroup, 0, len(work))
+	index := make(map[string]int, len(work))
+	for _, e := range work {
+		name := e.Where.Name
+		if i, ok := index[name]; ok && name != "" {
+			groups[i].Roles = append(groups[i].Roles, e)
+			continue
+		}
+		index[name] = len(groups)
+		groups = append(groups, EmployerGroup{Employer: e.Where, Roles: []rtype.Employment{e}})
+	}
+	return groups
+}
+
+// Section is a list section of a resume that has entries, as returned by sections.
+type Section struct {
+	Key   string // The section's key in resume files, such as "work" (see rtype.Sections).
+	Title string // The section's title, such as "Employment".
+	Count int    // The number of entries in the section.
+}
+
+// sectionTitles are the titles of the list sections of a resume, by their keys.
+var sectionTitles = map[string]string{
+	"work":         "Employment",
+	"education":    "Education",
+	"awards":       "Awards",
+	"publications": "Publications",
+	"references":   "References",
+}
+
+// resumeArg returns the resume given to the template function fn, either as an rtype.Resume or as the Data templates are
+// executed with.
+func resumeArg(fn string, resume interface{}) (rtype.Resume, error) {
+	switch v := resume.(type) {
+	case rtype.Resume:
+		return v, nil
+	case *rtype.Resume:
+		return *v, nil
+	case Data:
+		return v.Resume, nil
+	case *Data:
+		return v.Resume, nil
+	}
+	return rtype.Resume{}, fmt.Errorf("%s: %T is not a resume", fn, resume)
+}
+
+// sections returns the list sections of resume that have entries, in the order of rtype.Sections. The resume may be
+// given as an rtype.Resume or as the Data templates are executed with.
+func sections(resume interface{}) ([]Section, error) {
+	r, err := resumeArg("sections", resume)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int{
+		"work":         len(r.Employment),
+		"education":    len(r.Education),
+		"awards":       len(r.Awards),
+		"publications": len(r.Publications),
+		"references":   len(r.References),
+	}
+	list := make([]Section, 0, len(counts))
+	for _, key := range rtype.Sections {
+		if n := counts[key]; n > 0 {
+			list = append(list, Section{Key: key, Title: sectionTitles[key], Count: n})
+		}
+	}
+	return list, nil
+}
+
+// ContactLink is a way of contacting the resume's owner, as returned by contactLinks. It has the same URL and Label as a
+// linkify.Link, so it can be rendered with the "link" template.
+type ContactLink struct {
+	// Key is "email", "phone", or the key of a profile.
+	Key string
+
+	// URL is the link's URL as a *url.URL, except for tel: URLs in HTML templates, which are html/template URLs so that
+	// they aren't rejected as unsafe.
+	URL interface{}
+
+	// Label is the email address, the phone number as written, or the profile's label or, if it has none, its key.
+	Label string
+}
+
+// contactLinks returns links for contacting the owner of resume: a mailto: link for their email address, a tel: link for
+// their phone number (see telURI), and a link for each profile, in the order given by rtype.Profiles.Ordered. An empty
+// email address or phone number, a phone number that telURI can't use, and a profile without a valid URL are skipped.
+// The resume may be given as an rtype.Resume or as the Data templates are executed with.
+func contactLinks(resume interface{}) ([]ContactLink, error) {
+	r, err := resumeArg("contactLinks", resume)
+	if err != nil {
+		return nil, err
+	}
+
+	var links []ContactLink
+	if email := strings.TrimSpace(r.Me.Email); email != "" {
+		links = append(links, ContactLink{
+			Key:   "email",
+			URL:   &url.URL{Scheme: "mailto", Opaque: email},
+			Label: email,
+		})
+	}
+	if uri, ok := telURI(r.Me.Phone); ok {
+		links = append(links, ContactLink{
+			Key:   "phone",
+			URL:   &url.URL{Scheme: "tel", Opaque: strings.TrimPrefix(uri, "tel:")},
+			Label: strings.TrimSpace(r.Me.Phone),
+		})
+	}
+	for _, p := range r.Profiles.Ordered() {
+		u, err := url.Parse(strings.TrimSpace(p.URL))
+		if err != nil || p.URL == "" {
+			continue
+		}
+		label := p.Label
+		if label == "" {
+			label = p.Key
+		}
+		links = append(links, ContactLink{Key: p.Key, URL: u, Label: label})
+	}
+	return links, nil
+}
+
+// htmlContactLinks is contactLinks for HTML templates, which would otherwise replace tel: URLs with "#ZgotmplZ".
+func htmlContactLinks(resume interface{}) ([]ContactLink, error) {
+	links, err := contactLinks(resume)
+	for i, l := range links {
+		if u, ok := l.URL.(*url.URL); ok && u.Scheme == "tel" {
+			links[i].URL = htmlt.URL(u.String())
+		}
+	}
+	return links, err
+}
+
+// slug returns s as an anchor for use in URLs and as an element ID: letters are lowercased, runs of whitespace, hyphens, and
+// underscores become a single hyphen, and everything else other than letters and digits is removed. Leading and trailing
+// hyphens are trimmed, so text with no letters or digits gives an empty slug. Slugs are unchanged by slug.
+func slug(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range s {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			hyphen = false
+			b.WriteRune(unicode.ToLower(r))
+		case unicode.IsSpace(r) || r == '-' || r == '_':
+			hyphen = true
+		}
+	}
+	return b.String()
+}
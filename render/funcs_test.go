@@ -0,0 +1,784 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nilium/resify/rtype"
+)
+
+func TestFormatDate(t *testing.T) {
+	mustRange := func(from, to string) rtype.DateRange {
+		d, err := rtype.NewDateRange(from, to)
+		if err != nil {
+			t.Fatalf("cannot parse date range %q-%q: %v", from, to, err)
+		}
+		return d
+	}
+
+	table := []struct {
+		layout string
+		in     interface{}
+		want   string
+		ok     bool
+	}{
+		{"Jan 2006", time.Date(2015, 8, 1, 0, 0, 0, 0, time.UTC), "Aug 2015", true},
+		{"Jan 2006", time.Time{}, "", true},
+		{"Jan 2006", mustRange("2010-08", "2015-12"), "Aug 2010 - Dec 2015", true},
+		{"Jan 2006", mustRange("2010-08", ""), "Aug 2010", true},
+		{"Jan 2006", rtype.DateRange{}, "", true},
+		{"2006", mustRange("2010-08", "2015-12"), "2010 - 2015", true},
+		{"2006", "2015", "", false},
+	}
+
+	for _, e := range table {
+		got, err := formatDate(e.layout, e.in)
+		if (err == nil) != e.ok || got != e.want {
+			t.Errorf("formatDate(%q, %v) = %q, %v; expected %q", e.layout, e.in, got, err, e.want)
+		}
+	}
+
+	if got, err := formatYear(time.Date(2015, 8, 1, 0, 0, 0, 0, time.UTC)); err != nil || got != "2015" {
+		t.Errorf("formatYear(2015-08-01) = %q, %v; expected %q", got, err, "2015")
+	}
+}
+
+func TestSortByDate(t *testing.T) {
+	mustRange := func(from, to string) rtype.DateRange {
+		d, err := rtype.NewDateRange(from, to)
+		if err != nil {
+			t.Fatalf("cannot parse date range %q-%q: %v", from, to, err)
+		}
+		return d
+	}
+
+	work := []rtype.Employment{
+		{Title: "b", When: mustRange("2012", "2014")},
+		{Title: "ongoing", When: mustRange("2012", "")},
+		{Title: "a", When: mustRange("2010", "2011")},
+		{Title: "c", When: mustRange("2012", "2013")},
+		{Title: "undated"},
+	}
+
+	titles := func(v interface{}) (titles []string) {
+		for _, e := range v.([]rtype.Employment) {
+			titles = append(titles, e.Title)
+		}
+		return titles
+	}
+
+	table := []struct {
+		direction string
+		want      []string
+	}{
+		{"asc", []string{"undated", "a", "c", "b", "ongoing"}},
+		{"desc", []string{"ongoing", "b", "c", "a", "undated"}},
+	}
+
+	for _, e := range table {
+		sorted, err := sortByDate(work, e.direction)
+		if err != nil {
+			t.Errorf("unexpected error sorting %s: %v", e.direction, err)
+			continue
+		}
+		if got := titles(sorted); !reflect.DeepEqual(got, e.want) {
+			t.Errorf("expected %s order %q; got %q", e.direction, e.want, got)
+		}
+	}
+
+	if work[0].Title != "b" {
+		t.Error("sortByDate modified its input")
+	}
+
+	if sorted, err := sortByDate([]rtype.Education{}, "desc"); err != nil || len(sorted.([]rtype.Education)) != 0 {
+		t.Errorf("expected empty result sorting an empty slice; got %v, %v", sorted, err)
+	}
+
+	awards := []rtype.Award{
+		{Title: "b", Date: mustRange("2015-06", "")},
+		{Title: "a", Date: mustRange("2014", "")},
+	}
+	if sorted, err := sortByDate(awards, "asc"); err != nil || sorted.([]rtype.Award)[0].Title != "a" {
+		t.Errorf("expected awards sorted by date; got %v, %v", sorted, err)
+	}
+
+	pubs := []rtype.Publication{
+		{Title: "a", Date: mustRange("2014", "")},
+		{Title: "b", Date: mustRange("2016-03", "")},
+	}
+	if sorted, err := sortByDate(pubs, "desc"); err != nil || sorted.([]rtype.Publication)[0].Title != "b" {
+		t.Errorf("expected publications sorted by date; got %v, %v", sorted, err)
+	}
+
+	if _, err := sortByDate(work, "newest"); err == nil {
+		t.Error("expected an error for an unrecognized direction")
+	}
+
+	if _, err := sortByDate([]string{"a"}, "asc"); err == nil {
+		t.Error("expected an error sorting a slice without dates")
+	}
+}
+
+func TestFilterMeta(t *testing.T) {
+	work := []rtype.Employment{
+		{Title: "hidden", Meta: rtype.Meta{"hidden": true}},
+		{Title: "shown", Meta: rtype.Meta{"hidden": false}},
+		{Title: "no meta"},
+		{Title: "other meta", Meta: rtype.Meta{"manager": "M"}},
+		{Title: "hidden string", Meta: rtype.Meta{"hidden": "yes"}},
+	}
+
+	titles := func(v interface{}) (titles []string) {
+		for _, e := range v.([]rtype.Employment) {
+			titles = append(titles, e.Title)
+		}
+		return titles
+	}
+
+	got, err := filterMeta(work, "hidden")
+	if want := []string{"hidden", "hidden string"}; err != nil || !reflect.DeepEqual(titles(got), want) {
+		t.Errorf("expected %q to be hidden; got %q, %v", want, titles(got), err)
+	}
+
+	got, err = filterMeta(work, "hidden", false)
+	if want := []string{"shown", "no meta", "other meta"}; err != nil || !reflect.DeepEqual(titles(got), want) {
+		t.Errorf("expected %q to be shown; got %q, %v", want, titles(got), err)
+	}
+
+	if got, err := filterMeta([]rtype.Employment(nil), "hidden"); err != nil || len(titles(got)) != 0 {
+		t.Errorf("expected empty result filtering an empty slice; got %v, %v", got, err)
+	}
+
+	if _, err := filterMeta(work, "hidden", true, false); err == nil {
+		t.Error("expected an error when given more than one boolean")
+	}
+}
+
+func TestDataURI(t *testing.T) {
+	r := &Renderer{fsys: mapFS(map[string]string{
+		"dot.png": "\x89PNG\r\n\x1a\n",
+		"sniffed": "<html><body></body></html>",
+	}), debugf: t.Logf}
+
+	table := []struct {
+		path, want string
+	}{
+		{"dot.png", "data:image/png;base64,iVBORw0KGgo="},
+		{"/dot.png", "data:image/png;base64,iVBORw0KGgo="},
+		{"sniffed", "data:text/html;charset=utf-8;base64,PGh0bWw+PGJvZHk+PC9ib2R5PjwvaHRtbD4="},
+	}
+
+	for _, e := range table {
+		if got, err := r.dataURI(e.path); err != nil || got != e.want {
+			t.Errorf("dataURI(%q) = %q, %v; want %q", e.path, got, err, e.want)
+		}
+	}
+
+	for _, path := range []string{"../secret.txt", "sub/../../secret.txt"} {
+		if _, err := r.dataURI(path); err != ErrEscapeAttempt {
+			t.Errorf("dataURI(%q) error = %v; want %v", path, err, ErrEscapeAttempt)
+		}
+	}
+}
+
+func TestReadFileCache(t *testing.T) {
+	fsys := mapFS(map[string]string{"style.css": "old"})
+
+	r := &Renderer{fsys: fsys, debugf: t.Logf}
+
+	if got, err := r.readFile("style.css"); err != nil || got != "old" {
+		t.Fatalf("readFile(%q) = %q, %v; want %q", "style.css", got, err, "old")
+	}
+
+	fsys["style.css"].Data = []byte("new")
+	if got, err := r.readFile("./style.css"); err != nil || got != "old" {
+		t.Errorf("readFile(%q) after change = %q, %v; want cached %q", "./style.css", got, err, "old")
+	}
+
+	r = &Renderer{fsys: fsys, debugf: t.Logf}
+	if got, err := r.readFile("style.css"); err != nil || got != "new" {
+		t.Errorf("readFile(%q) with a new renderer = %q, %v; want %q", "style.css", got, err, "new")
+	}
+}
+
+func TestFileCacheConcurrentReads(t *testing.T) {
+	var c fileCache
+
+	// Reading one file doesn't hold up reading another: a's read only finishes once b has been read.
+	bRead := make(chan struct{})
+	errc := make(chan error, 2)
+	go func() {
+		_, err := c.get("a", func() ([]byte, error) {
+			select {
+			case <-bRead:
+				return []byte("a"), nil
+			case <-time.After(5 * time.Second):
+				return nil, errors.New("timed out waiting for b to be read")
+			}
+		})
+		errc <- err
+	}()
+	go func() {
+		_, err := c.get("b", func() ([]byte, error) { return []byte("b"), nil })
+		close(bRead)
+		errc <- err
+	}()
+	for i := 0; i < 2; i++ {
+		if err := <-errc; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Concurrent gets of the same file read it once.
+	var reads int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b, err := c.get("c", func() ([]byte, error) {
+				atomic.AddInt32(&reads, 1)
+				time.Sleep(10 * time.Millisecond)
+				return []byte("c"), nil
+			})
+			if err != nil || string(b) != "c" {
+				t.Errorf("get(c) = %q, %v; want %q", b, err, "c")
+			}
+		}()
+	}
+	wg.Wait()
+	if reads != 1 {
+		t.Errorf("c was read %d times; want 1", reads)
+	}
+
+	// Errors aren't cached.
+	if _, err := c.get("d", func() ([]byte, error) { return nil, errors.New("failed") }); err == nil {
+		t.Error("expected get(d) to return read's error")
+	}
+	if b, err := c.get("d", func() ([]byte, error) { return []byte("d"), nil }); err != nil || string(b) != "d" {
+		t.Errorf("get(d) after an error = %q, %v; want %q", b, err, "d")
+	}
+}
+
+func TestMissingEmbed(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"index.tem":         `<p>{{ template "partials/head.tem" }}</p>`,
+		"partials/head.tem": `{{ embed "style.css" }}{{ embed "missing.css" }}`,
+		"style.css":         "body{}",
+	})
+
+	for _, text := range []bool{false, true} {
+		err := Render(ioutil.Discard, rtype.Resume{}, fsys, Options{Text: text, Ext: ".tem"})
+		var missing *MissingEmbedError
+		if !errors.As(err, &missing) {
+			t.Errorf("expected MissingEmbedError (text=%t); got %v", text, err)
+			continue
+		}
+		if !strings.HasPrefix(missing.Template, "partials/head.tem:1:") || missing.Path != "missing.css" {
+			t.Errorf("MissingEmbedError (text=%t) = %+v; want Template partials/head.tem:1:* and Path missing.css", text, missing)
+		}
+		if msg := err.Error(); !strings.Contains(msg, "partials/head.tem") || !strings.Contains(msg, `"missing.css"`) {
+			t.Errorf("error (text=%t) %q should name the template and the missing file", text, msg)
+		}
+	}
+
+	var warnings []string
+	warnf := func(format string, args ...interface{}) { warnings = append(warnings, fmt.Sprintf(format, args...)) }
+	var buf strings.Builder
+	err := Render(&buf, rtype.Resume{}, fsys, Options{Ext: ".tem", IgnoreMissingEmbeds: true, Warnf: warnf})
+	if err != nil {
+		t.Fatalf("unexpected error ignoring missing embeds: %v", err)
+	}
+	if want := "<p>body{}</p>"; buf.String() != want {
+		t.Errorf("expected %q ignoring missing embeds; got %q", want, buf.String())
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "missing.css") {
+		t.Errorf("expected one warning about missing.css; got %q", warnings)
+	}
+
+	// Only files that don't exist are ignored.
+	fsys["index.tem"].Data = []byte(`{{ embed "../secret.css" }}`)
+	err = Render(ioutil.Discard, rtype.Resume{}, fsys, Options{Ext: ".tem", IgnoreMissingEmbeds: true, Warnf: warnf})
+	if !errors.Is(err, ErrEscapeAttempt) {
+		t.Errorf("expected %v ignoring missing embeds; got %v", ErrEscapeAttempt, err)
+	}
+}
+
+func TestFormatPhone(t *testing.T) {
+	table := []struct {
+		in, phone, tel string
+	}{
+		{"+12345678901", "+1 (234) 567-8901", "tel:+12345678901"},
+		{"+1 234-567-8901", "+1 (234) 567-8901", "tel:+12345678901"},
+		{"(234) 567.8901", "(234) 567-8901", "tel:2345678901"},
+		{"+44 20 7946 0958", "+44 20 7946 0958", "tel:+442079460958"},
+		{"555-CALL-NOW", "555-CALL-NOW", "555-CALL-NOW"},
+		{"12+34567890", "12+34567890", "12+34567890"},
+		{"123", "123", "123"},
+		{"", "", ""},
+	}
+
+	for _, e := range table {
+		if got := formatPhone(e.in); got != e.phone {
+			t.Errorf("formatPhone(%q) = %q; want %q", e.in, got, e.phone)
+		}
+		if got, _ := telURI(e.in); got != e.tel {
+			t.Errorf("telURI(%q) = %q; want %q", e.in, got, e.tel)
+		}
+	}
+}
+
+func TestObfuscate(t *testing.T) {
+	const email = "jane.doe+resume@example.com"
+
+	got := obfuscate(email)
+	if strings.ContainsAny(got, "@.") || strings.Contains(got, "example") {
+		t.Errorf("obfuscate(%q) = %q; expected no readable characters", email, got)
+	}
+	if decoded := html.UnescapeString(got); decoded != email {
+		t.Errorf("obfuscate(%q) decodes to %q", email, decoded)
+	}
+
+	link := obfuscateMailto(email)
+	if decoded, want := html.UnescapeString(link), `<a href="mailto:`+email+`">`+email+`</a>`; decoded != want {
+		t.Errorf("obfuscateMailto(%q) decodes to %q; want %q", email, decoded, want)
+	}
+}
+
+func TestMetaAccessors(t *testing.T) {
+	meta := rtype.Meta{
+		"manager":  "Damien",
+		"reports":  3,
+		"big":      int64(1) << 40,
+		"ratio":    1.5,
+		"whole":    2.0,
+		"count":    " 12 ",
+		"hidden":   true,
+		"remote":   "yes",
+		"archived": "false",
+		"nothing":  nil,
+	}
+
+	strs := []struct {
+		key, def, want string
+	}{
+		{"manager", "", "Damien"},
+		{"reports", "", "3"},
+		{"missing", "", ""},
+		{"missing", "N/A", "N/A"},
+		{"nothing", "N/A", "N/A"},
+	}
+	for _, e := range strs {
+		var def []string
+		if e.def != "" {
+			def = []string{e.def}
+		}
+		if got, err := metaStr(meta, e.key, def...); err != nil || got != e.want {
+			t.Errorf("metaStr(%q, %q) = %q, %v; want %q", e.key, def, got, err, e.want)
+		}
+	}
+
+	bools := []struct {
+		key       string
+		def, want bool
+	}{
+		{"hidden", false, true},
+		{"archived", true, false},
+		{"remote", true, true},
+		{"manager", false, false},
+		{"missing", true, true},
+	}
+	for _, e := range bools {
+		if got, err := metaBool(meta, e.key, e.def); err != nil || got != e.want {
+			t.Errorf("metaBool(%q, %v) = %v, %v; want %v", e.key, e.def, got, err, e.want)
+		}
+	}
+
+	ints := []struct {
+		key       string
+		def, want int
+	}{
+		{"reports", -1, 3},
+		{"big", -1, 1 << 40},
+		{"whole", -1, 2},
+		{"count", -1, 12},
+		{"ratio", -1, -1},
+		{"manager", -1, -1},
+		{"missing", 7, 7},
+	}
+	for _, e := range ints {
+		if got, err := metaInt(meta, e.key, e.def); err != nil || got != e.want {
+			t.Errorf("metaInt(%q, %d) = %d, %v; want %d", e.key, e.def, got, err, e.want)
+		}
+	}
+
+	if got, err := metaInt(meta, "missing"); err != nil || got != 0 {
+		t.Errorf("metaInt without default = %d, %v; want 0", got, err)
+	}
+	if _, err := metaStr(meta, "missing", "a", "b"); err == nil {
+		t.Error("expected an error for more than one default")
+	}
+
+	// Maps of strings, such as .Extra, are read the same way.
+	extra := map[string]string{"year": "2024", "draft": "true", "name": "Foobiz"}
+	if got, err := metaInt(extra, "year"); err != nil || got != 2024 {
+		t.Errorf("metaInt of string map = %d, %v; want 2024", got, err)
+	}
+	if got, err := metaBool(extra, "draft"); err != nil || !got {
+		t.Errorf("metaBool of string map = %v, %v; want true", got, err)
+	}
+	if got, err := metaStr(extra, "missing", "N/A"); err != nil || got != "N/A" {
+		t.Errorf("metaStr of string map = %q, %v; want N/A", got, err)
+	}
+	if got, err := metaStr(nil, "missing"); err != nil || got != "" {
+		t.Errorf("metaStr of nil = %q, %v; want empty", got, err)
+	}
+	if _, err := metaStr([]string{"name"}, "name"); err == nil {
+		t.Error("expected an error for a value that isn't a map")
+	}
+}
+
+func TestCountWords(t *testing.T) {
+	long := strings.Repeat("word ", 201)
+
+	table := []struct {
+		in      string
+		words   int
+		minutes int
+	}{
+		{"", 0, 0},
+		{" \t\n ", 0, 0},
+		{"one", 1, 1},
+		{"one  two\nthree\t\tfour ", 4, 1},
+		{"see ((https://example.com/a/long/path my site)) now", 4, 1},
+		{"see [my site](https://example.com/a/long/path \"title\") now", 4, 1},
+		{"((https://example.com/path))", 1, 1},
+		{long, 201, 2},
+	}
+
+	for _, e := range table {
+		if got := countWords(e.in); got != e.words {
+			t.Errorf("countWords(%q) = %d; want %d", e.in, got, e.words)
+		}
+		if got := readingTime(e.in); got != e.minutes {
+			t.Errorf("readingTime(%q) = %d; want %d", e.in, got, e.minutes)
+		}
+	}
+}
+
+func TestSlug(t *testing.T) {
+	table := []struct {
+		in, want string
+	}{
+		{"Software Engineer", "software-engineer"},
+		{"  Senior Engineer, Platform & Infra  ", "senior-engineer-platform-infra"},
+		{"Don't Panic", "dont-panic"},
+		{"snake_case -- and - hyphens", "snake-case-and-hyphens"},
+		{"C++ / Go", "c-go"},
+		{"Université de Montréal", "université-de-montréal"},
+		{"東京大学 2010", "東京大学-2010"},
+		{"ÀÉÎ", "àéî"},
+		{"", ""},
+		{"!!!", ""},
+		{" - _ ", ""},
+		{"--a--", "a"},
+	}
+
+	for _, e := range table {
+		got := slug(e.in)
+		if got != e.want {
+			t.Errorf("slug(%q) = %q; want %q", e.in, got, e.want)
+		}
+		if again := slug(got); again != got {
+			t.Errorf("slug(%q) = %q; want it unchanged", got, again)
+		}
+	}
+}
+
+func TestURLFunc(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"index.tem": `<a href="{{ .Meta.site }}"></a><a href="{{ url .Meta.site }}"></a>`,
+	})
+
+	resume := rtype.Resume{Meta: rtype.Meta{"site": "data:text/plain,hi"}}
+	table := []struct {
+		text bool
+		want string
+	}{
+		{true, `<a href="data:text/plain,hi"></a><a href="data:text/plain,hi"></a>`},
+		{false, `<a href="#ZgotmplZ"></a><a href="data:text/plain,hi"></a>`},
+	}
+
+	for _, e := range table {
+		var buf strings.Builder
+		if err := Render(&buf, resume, fsys, Options{Text: e.text, Ext: ".tem"}); err != nil {
+			t.Errorf("unexpected error rendering (text=%t): %v", e.text, err)
+		} else if buf.String() != e.want {
+			t.Errorf("expected %q; got %q", e.want, buf.String())
+		}
+	}
+}
+
+func TestSrcset(t *testing.T) {
+	r := &Renderer{fsys: mapFS(map[string]string{
+		"img/me-1.png":      "\x89PNG\r\n\x1a\n",
+		"img/me, large.png": "\x89PNG\r\n\x1a\n",
+	}), debugf: t.Logf}
+
+	table := []struct {
+		inline bool
+		images []string
+		want   string
+	}{
+		{false, []string{"img/me-1.png 320w", "/img/me, large.png 640w"}, "img/me-1.png 320w, img/me%2C%20large.png 640w"},
+		{false, []string{"img/me-1.png", "img/me-1.png 1.5x"}, "img/me-1.png, img/me-1.png 1.5x"},
+		{true, []string{"img/me-1.png 2x"}, "data:image/png;base64,iVBORw0KGgo= 2x"},
+	}
+
+	for _, e := range table {
+		if got, err := r.srcset(e.inline, e.images); err != nil || got != e.want {
+			t.Errorf("srcset(%t, %q) = %q, %v; want %q", e.inline, e.images, got, err, e.want)
+		}
+	}
+
+	for _, inline := range []bool{false, true} {
+		if _, err := r.srcset(inline, []string{"../secret.png 320w"}); err != ErrEscapeAttempt {
+			t.Errorf("srcset(%t) of an escaping path error = %v; want %v", inline, err, ErrEscapeAttempt)
+		}
+	}
+
+	for _, images := range [][]string{nil, {"missing.png"}, {" 320w"}, {"img/me-1.png wide"}, {"img/me-1.png 320w 2x"}} {
+		if got, err := r.srcset(false, images); err == nil {
+			t.Errorf("srcset(%q) = %q; expected an error", images, got)
+		}
+	}
+}
+
+func TestSrcsetFunc(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"index.tem": `<img srcset="{{ srcset "me.png 320w" }}"><img srcset="{{ dataSrcset "me.png 1x" }}">`,
+		"me.png":    "\x89PNG\r\n\x1a\n",
+	})
+
+	var buf strings.Builder
+	if err := Render(&buf, rtype.Resume{}, fsys, Options{Ext: ".tem"}); err != nil {
+		t.Fatalf("unexpected error rendering: %v", err)
+	}
+	if want := `<img srcset="me.png 320w"><img srcset="data:image/png;base64,iVBORw0KGgo= 1x">`; buf.String() != want {
+		t.Errorf("expected %q; got %q", want, buf.String())
+	}
+}
+
+func TestTotalExperience(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"index.tem": `{{ totalExperience .Employment }} of experience`,
+	})
+
+	mustRange := func(from, to string) rtype.DateRange {
+		d, err := rtype.NewDateRange(from, to)
+		if err != nil {
+			t.Fatalf("cannot parse date range %q-%q: %v", from, to, err)
+		}
+		return d
+	}
+
+	resume := rtype.Resume{Employment: []rtype.Employment{
+		{When: mustRange("2012-01", "2014-03")},
+		{When: mustRange("2010-01", "2012-06")},
+	}}
+
+	var buf strings.Builder
+	if err := Render(&buf, resume, fsys, Options{Ext: ".tem"}); err != nil {
+		t.Fatalf("unexpected error rendering: %v", err)
+	}
+	if want := "4 years, 2 months of experience"; buf.String() != want {
+		t.Errorf("expected %q; got %q", want, buf.String())
+	}
+}
+
+func TestGroupByEmployer(t *testing.T) {
+	role := func(employer, title string) rtype.Employment {
+		return rtype.Employment{Title: title, Where: rtype.Place{Name: employer}}
+	}
+
+	table := []struct {
+		work []rtype.Employment
+		want []EmployerGroup
+	}{
+		{nil, []EmployerGroup{}},
+		{
+			[]rtype.Employment{role("Foobiz", "Engineer")},
+			[]EmployerGroup{{rtype.Place{Name: "Foobiz"}, []rtype.Employment{role("Foobiz", "Engineer")}}},
+		},
+		{
+			[]rtype.Employment{role("Foobiz", "Senior Engineer"), role("Foobiz", "Engineer"), role("Barco", "Intern")},
+			[]EmployerGroup{
+				{rtype.Place{Name: "Foobiz"}, []rtype.Employment{role("Foobiz", "Senior Engineer"), role("Foobiz", "Engineer")}},
+				{rtype.Place{Name: "Barco"}, []rtype.Employment{role("Barco", "Intern")}},
+			},
+		},
+		{
+			// Non-contiguous roles are grouped under the employer's first appearance.
+			[]rtype.Employment{role("Foobiz", "Lead"), role("Barco", "Engineer"), role("Foobiz", "Engineer")},
+			[]EmployerGroup{
+				{rtype.Place{Name: "Foobiz"}, []rtype.Employment{role("Foobiz", "Lead"), role("Foobiz", "Engineer")}},
+				{rtype.Place{Name: "Barco"}, []rtype.Employment{role("Barco", "Engineer")}},
+			},
+		},
+		{
+			// Roles without an employer aren't grouped together.
+			[]rtype.Employment{role("", "Freelancer"), role("", "Consultant")},
+			[]EmployerGroup{
+				{rtype.Place{}, []rtype.Employment{role("", "Freelancer")}},
+				{rtype.Place{}, []rtype.Employment{role("", "Consultant")}},
+			},
+		},
+	}
+
+	for _, e := range table {
+		if got := groupByEmployer(e.work); !reflect.DeepEqual(got, e.want) {
+			t.Errorf("groupByEmployer(%v) = %v; want %v", e.work, got, e.want)
+		}
+	}
+
+	fsys := mapFS(map[string]string{
+		"index.tem": `{{ range groupByEmployer .Employment }}{{ .Employer.Name }}:` +
+			`{{ range .Roles }} {{ .Title }};{{ end }} {{ end }}`,
+	})
+	resume := rtype.Resume{Employment: table[3].work}
+
+	var buf strings.Builder
+	if err := Render(&buf, resume, fsys, Options{Text: true, Ext: ".tem"}); err != nil {
+		t.Fatalf("unexpected error rendering: %v", err)
+	}
+	if want := "Foobiz: Lead; Engineer; Barco: Engineer; "; buf.String() != want {
+		t.Errorf("expected %q; got %q", want, buf.String())
+	}
+}
+
+func TestSections(t *testing.T) {
+	resume := rtype.Resume{
+		Employment: []rtype.Employment{{Title: "A"}, {Title: "B"}},
+		Awards:     []rtype.Award{{Title: "C"}},
+		References: []rtype.Reference{},
+	}
+	want := []Section{{"work", "Employment", 2}, {"awards", "Awards", 1}}
+
+	for _, in := range []interface{}{resume, &resume, Data{Resume: resume}, &Data{Resume: resume}} {
+		if got, err := sections(in); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("sections(%T) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if got, err := sections(rtype.Resume{}); err != nil || len(got) != 0 {
+		t.Errorf("sections of an empty resume = %v, %v; want none", got, err)
+	}
+	if _, err := sections(resume.Employment); err == nil {
+		t.Error("expected an error listing the sections of a slice")
+	}
+
+	fsys := mapFS(map[string]string{
+		"index.tem": `{{ range sections . }}<a href="#{{ slug .Title }}">{{ .Title }} ({{ .Count }})</a>{{ end }}`,
+	})
+	var buf strings.Builder
+	if err := Render(&buf, resume, fsys, Options{Ext: ".tem"}); err != nil {
+		t.Fatalf("unexpected error rendering sections: %v", err)
+	}
+	if want := `<a href="#employment">Employment (2)</a><a href="#awards">Awards (1)</a>`; buf.String() != want {
+		t.Errorf("expected %q; got %q", want, buf.String())
+	}
+}
+
+func TestContactLinks(t *testing.T) {
+	resume := rtype.Resume{
+		Me: rtype.Me{Email: "me@example.com", Phone: "+1 (234) 567-8901"},
+		Profiles: rtype.Profiles{
+			Order: []string{"twitter"},
+			Profile: map[string]rtype.Profile{
+				"github":  {URL: "https://github.com/me"},
+				"twitter": {URL: "https://twitter.com/me", Label: "Twitter"},
+				"empty":   {Label: "Nothing"},
+			},
+		},
+	}
+
+	fsys := mapFS(map[string]string{
+		"index.tem": `{{ range contactLinks . }}{{ .Key }}:{{ template "link" . }};{{ end }}`,
+	})
+	table := []struct {
+		text bool
+		want string
+	}{
+		{false, `email:<a href="mailto:me@example.com">me@example.com</a>;` +
+			`phone:<a href="tel:&#43;12345678901">&#43;1 (234) 567-8901</a>;` +
+			`twitter:<a href="https://twitter.com/me">Twitter</a>;` +
+			`github:<a href="https://github.com/me">github</a>;`},
+		{true, `email:me@example.com (mailto:me@example.com);` +
+			`phone:+1 (234) 567-8901 (tel:+12345678901);` +
+			`twitter:Twitter (https://twitter.com/me);` +
+			`github:github (https://github.com/me);`},
+	}
+
+	for _, e := range table {
+		var buf strings.Builder
+		if err := Render(&buf, resume, fsys, Options{Text: e.text, Ext: ".tem"}); err != nil {
+			t.Errorf("unexpected error rendering contact links (text=%t): %v", e.text, err)
+		} else if buf.String() != e.want {
+			t.Errorf("contact links (text=%t) = %q; want %q", e.text, buf.String(), e.want)
+		}
+	}
+
+	// Missing and unusable fields are skipped.
+	links, err := contactLinks(rtype.Resume{Me: rtype.Me{Phone: "ask me"}})
+	if err != nil || len(links) != 0 {
+		t.Errorf("contactLinks with no usable fields = %v, %v; want none", links, err)
+	}
+	if _, err := contactLinks("me@example.com"); err == nil {
+		t.Error("expected an error for a string")
+	}
+}
+
+func TestPhoto(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"index.tem": `<img src="{{ photo .Me.Photo }}">`,
+		"me.png":    "\x89PNG\r\n\x1a\n",
+	})
+
+	table := []struct {
+		photo string
+		text  bool
+		want  string
+	}{
+		{"https://example.com/me.jpg?size=2", false, `<img src="https://example.com/me.jpg?size=2">`},
+		{"me.png", false, `<img src="data:image/png;base64,iVBORw0KGgo=">`},
+		{"/me.png", false, `<img src="data:image/png;base64,iVBORw0KGgo=">`},
+		{"", false, `<img src="">`},
+		{"me.png", true, `<img src="me.png">`},
+		{"https://example.com/me.jpg", true, `<img src="https://example.com/me.jpg">`},
+	}
+
+	for _, e := range table {
+		var resume rtype.Resume
+		resume.Me.Photo = e.photo
+
+		var buf strings.Builder
+		if err := Render(&buf, resume, fsys, Options{Text: e.text, Ext: ".tem"}); err != nil {
+			t.Errorf("unexpected error rendering photo %q (text=%t): %v", e.photo, e.text, err)
+		} else if buf.String() != e.want {
+			t.Errorf("expected %q; got %q", e.want, buf.String())
+		}
+	}
+
+	var resume rtype.Resume
+	resume.Me.Photo = "../me.png"
+	if err := Render(ioutil.Discard, resume, fsys, Options{Ext: ".tem"}); !errors.Is(err, ErrEscapeAttempt) {
+		t.Errorf("expected %v rendering a photo outside the templates; got %v", ErrEscapeAttempt, err)
+	}
+}
@@ -0,0 +1,139 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"text/template/parse"
+)
+
+// loadTemplates walks fsys and calls parse for every file in it ending in ext, in lexical order. Each template is named by
+// its path in fsys, so top-level templates keep their file names (e.g., "index.tem") and templates in subdirectories are
+// named like "partials/header.tem". Templates are parsed with the left and right action delimiters given by delims. Any
+// front matter is removed from a template's source and passed to parse separately (see FrontMatter).
+//
+// Templates defined in one file (by name or with define) may not be defined again in another file. If that happens, an error
+// naming both files is returned before parse is called for the second file. It is also an error for no templates to be
+// found.
+func loadTemplates(fsys fs.FS, ext string, delims [2]string, parseFile func(name, src string, front FrontMatter) error) error {
+	definedIn := map[string]string{}
+	found := false
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ext) {
+			return nil
+		}
+
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		front, src, err := splitFrontMatter(string(b))
+		if err != nil {
+			return fmt.Errorf("%s: cannot parse front matter: %w", name, err)
+		}
+
+		names, err := definedTemplates(name, src, delims)
+		if err != nil {
+			return err
+		}
+		for _, defined := range names {
+			if prev, ok := definedIn[defined]; ok {
+				return fmt.Errorf("template %q is defined in both %s and %s", defined, prev, name)
+			}
+			definedIn[defined] = name
+		}
+
+		found = true
+		return parseFile(name, src, front)
+	})
+
+	if err == nil && !found {
+		err = noTemplatesError{ext: ext}
+	}
+	return err
+}
+
+// noTemplatesError is returned by loadTemplates when no templates are found.
+type noTemplatesError struct {
+	ext string
+}
+
+func (e noTemplatesError) Error() string {
+	return fmt.Sprintf("no %s templates found", e.ext)
+}
+
+// isMissingTemplates returns whether err, returned by loadTemplates, is only because there were no templates to load.
+func isMissingTemplates(err error) bool {
+	var none noTemplatesError
+	return errors.As(err, &none) || errors.Is(err, fs.ErrNotExist)
+}
+
+// noSuchTemplateError is returned by New when the main template isn't defined by the loaded templates.
+type noSuchTemplateError struct {
+	name      string
+	available []string
+	defaulted bool // Whether name is the default index template, rather than one that was asked for.
+}
+
+func (e noSuchTemplateError) Error() string {
+	if e.defaulted && len(e.available) > 0 {
+		return fmt.Sprintf("no %s template to render by default; name one of: %s", e.name, strings.Join(e.available, ", "))
+	}
+	if len(e.available) == 0 {
+		return fmt.Sprintf("no such template %q; no templates are defined", e.name)
+	}
+	return fmt.Sprintf("no such template %q; available templates: %s", e.name, strings.Join(e.available, ", "))
+}
+
+// executableNames returns the sorted names of the trees that aren't empty, and so can be executed.
+func executableNames(trees []*parse.Tree) []string {
+	names := make([]string, 0, len(trees))
+	for _, tree := range trees {
+		if tree != nil && tree.Root != nil && !parse.IsEmptyTree(tree.Root) {
+			names = append(names, tree.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// definedTemplates returns the names of the non-empty templates defined by src, including name itself if src has content
+// outside of define blocks.
+func definedTemplates(name, src string, delims [2]string) ([]string, error) {
+	t := parse.New(name)
+	t.Mode = parse.SkipFuncCheck
+	trees := map[string]*parse.Tree{}
+	if _, err := t.Parse(src, delims[0], delims[1], trees); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(trees))
+	for n, tree := range trees {
+		if tree.Root != nil && !parse.IsEmptyTree(tree.Root) {
+			names = append(names, n)
+		}
+	}
+	return names, nil
+}
+
+// MainTemplateName is the name given to a main template passed as Options.TemplateSource instead of loaded from the
+// templates filesystem.
+const MainTemplateName = "<main>"
+
+// resolveTemplate returns the name of the main template to execute. An empty name is the index template: "index" followed by
+// ext. If no template is defined with the given name but one is defined with ext appended to it, that name is returned
+// instead, so a name of "resume" finds resume.tem.
+func resolveTemplate(name, ext string, defined func(string) bool) string {
+	if name == "" {
+		return "index" + ext
+	}
+	if !defined(name) && defined(name+ext) {
+		return name + ext
+	}
+	return name
+}
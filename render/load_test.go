@@ -0,0 +1,391 @@
+package render
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/nilium/resify/rtype"
+)
+
+func mapFS(files map[string]string) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for name, src := range files {
+		fsys[name] = &fstest.MapFile{Data: []byte(src)}
+	}
+	return fsys
+}
+
+func TestLoadTemplates(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"index.tem":            `{{ template "partials/head.tem" . }}`,
+		"notes.txt":            `not a template`,
+		"partials/head.tem":    `{{ define "title" }}Title{{ end }}`,
+		"sections/work.tem":    `Work`,
+		"sections/nested/.tem": ``,
+	})
+
+	var names []string
+	err := loadTemplates(fsys, ".tem", [2]string{}, func(name, src string, front FrontMatter) error {
+		names = append(names, name)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error loading templates: %v", err)
+	}
+
+	want := []string{"index.tem", "partials/head.tem", "sections/nested/.tem", "sections/work.tem"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("expected templates %q; got %q", want, names)
+	}
+}
+
+func TestLoadTemplatesCollision(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"a/one.tem": `{{ define "shared" }}One{{ end }}`,
+		"b/two.tem": `{{ define "shared" }}Two{{ end }}`,
+	})
+
+	err := loadTemplates(fsys, ".tem", [2]string{}, func(name, src string, front FrontMatter) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), `"shared" is defined in both a/one.tem and b/two.tem`) {
+		t.Errorf("expected collision error; got %v", err)
+	}
+}
+
+func TestLoadTemplatesEmpty(t *testing.T) {
+	if err := loadTemplates(fstest.MapFS{}, ".tem", [2]string{}, func(name, src string, front FrontMatter) error { return nil }); err == nil {
+		t.Error("expected an error loading an empty directory")
+	} else if !isMissingTemplates(err) {
+		t.Errorf("expected a missing templates error; got %v", err)
+	}
+}
+
+func TestResolveTemplate(t *testing.T) {
+	defined := func(name string) bool {
+		return name == "index.html.tmpl" || name == "resume.html.tmpl" || name == "resume"
+	}
+
+	table := []struct {
+		name, ext, want string
+	}{
+		{"", ".html.tmpl", "index.html.tmpl"},
+		{"", ".tem", "index.tem"},
+		{"resume", ".html.tmpl", "resume"},
+		{"resume.html.tmpl", ".html.tmpl", "resume.html.tmpl"},
+		{"index", ".html.tmpl", "index.html.tmpl"},
+		{"missing", ".html.tmpl", "missing"},
+	}
+
+	for _, e := range table {
+		if got := resolveTemplate(e.name, e.ext, defined); got != e.want {
+			t.Errorf("resolveTemplate(%q, %q) = %q; expected %q", e.name, e.ext, got, e.want)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"resume.tem":        `{{ template "partials/name.tem" . }} {{ embed "style.css" }} {{ linkify "((https://example.com Example))" }}`,
+		"partials/name.tem": `{{ .Me.Chosen }}`,
+		"style.css":         "h1 {}",
+	})
+
+	var resume rtype.Resume
+	resume.Me.Chosen = "Jane"
+
+	var buf strings.Builder
+	if err := Render(&buf, resume, fsys, Options{Text: true, Ext: ".tem", Template: "resume"}); err != nil {
+		t.Fatalf("unexpected error rendering: %v", err)
+	}
+	if want := "Jane h1 {} Example (https://example.com)"; buf.String() != want {
+		t.Errorf("expected %q; got %q", want, buf.String())
+	}
+}
+
+func TestNewTemplateSource(t *testing.T) {
+	fsys := mapFS(map[string]string{"style.css": "h1 {}"})
+	opts := Options{
+		Text:           true,
+		Ext:            ".tem",
+		Template:       "ignored",
+		TemplateSource: `{{ embed "style.css" }} {{ linkify "((https://example.com Example))" }}`,
+	}
+
+	r, err := New(fsys, opts)
+	if err != nil {
+		t.Fatalf("unexpected error loading template source: %v", err)
+	}
+	if name := r.Name(); name != MainTemplateName {
+		t.Errorf("expected main template %q; got %q", MainTemplateName, name)
+	}
+
+	var buf strings.Builder
+	if err = r.Render(&buf, rtype.Resume{}); err != nil {
+		t.Fatalf("unexpected error executing template source: %v", err)
+	}
+	if want := "h1 {} Example (https://example.com)"; buf.String() != want {
+		t.Errorf("expected %q; got %q", want, buf.String())
+	}
+}
+
+func TestNewDelims(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"index.tem":         `{{ client }} [[ template "partials/name.tem" . ]] [[ linkify "((https://example.com Example))" ]]`,
+		"partials/name.tem": `[[ .Me.Chosen ]]`,
+	})
+
+	r, err := New(fsys, Options{Ext: ".tem", Delims: [2]string{"[[", "]]"}})
+	if err != nil {
+		t.Fatalf("unexpected error loading templates: %v", err)
+	}
+
+	var resume rtype.Resume
+	resume.Me.Chosen = "Jane"
+
+	var buf strings.Builder
+	if err = r.Render(&buf, resume); err != nil {
+		t.Fatalf("unexpected error executing template: %v", err)
+	}
+	if want := `{{ client }} Jane <a href="https://example.com">Example</a>`; buf.String() != want {
+		t.Errorf("expected %q; got %q", want, buf.String())
+	}
+}
+
+func TestRenderersIndependent(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"index.tem": `{{ linkify "<((https://example.com Example))>" }}`,
+	})
+
+	text, err := New(fsys, Options{Text: true, Ext: ".tem"})
+	if err != nil {
+		t.Fatalf("unexpected error loading text templates: %v", err)
+	}
+	html, err := New(fsys, Options{Ext: ".tem"})
+	if err != nil {
+		t.Fatalf("unexpected error loading HTML templates: %v", err)
+	}
+
+	table := []struct {
+		r    *Renderer
+		want string
+	}{
+		{text, "<Example (https://example.com)>"},
+		{html, `&lt;<a href="https://example.com">Example</a>&gt;`},
+	}
+
+	for _, e := range table {
+		var buf strings.Builder
+		if err := e.r.Render(&buf, rtype.Resume{}); err != nil {
+			t.Errorf("unexpected error rendering: %v", err)
+		} else if buf.String() != e.want {
+			t.Errorf("expected %q; got %q", e.want, buf.String())
+		}
+		if got := e.r.Linkify("((https://example.com Example))"); !strings.Contains(e.want, got) {
+			t.Errorf("Linkify = %q; expected it in %q", got, e.want)
+		}
+	}
+}
+
+func TestLink(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"index.tem": `{{ range .Publications }}[{{ link .URL .Title }}]{{ end }}`,
+	})
+
+	resume := rtype.Resume{
+		Publications: []rtype.Publication{
+			{Title: "Paper & Pen", URL: "https://example.com/paper"},
+			{URL: "https://example.com/untitled"},
+			{Title: "Offline <draft>"},
+		},
+	}
+
+	table := []struct {
+		text bool
+		want string
+	}{
+		{true, "[Paper & Pen (https://example.com/paper)][example.com/untitled (https://example.com/untitled)][Offline <draft>]"},
+		{false, `[<a href="https://example.com/paper">Paper &amp; Pen</a>]` +
+			`[<a href="https://example.com/untitled">example.com/untitled</a>][Offline &lt;draft&gt;]`},
+	}
+
+	for _, e := range table {
+		var buf strings.Builder
+		if err := Render(&buf, resume, fsys, Options{Text: e.text, Ext: ".tem"}); err != nil {
+			t.Errorf("unexpected error rendering (text=%t): %v", e.text, err)
+		} else if buf.String() != e.want {
+			t.Errorf("expected %q; got %q", e.want, buf.String())
+		}
+	}
+}
+
+func TestMarkdownOutput(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"index.tem": `{{ range .Publications }}- {{ link .URL .Title }}{{ "\n" }}{{ end }}` +
+			`{{ with index .Employment 0 }}{{ escape .Title }}: {{ linkify .Description | markdown }}{{ end }}`,
+	})
+
+	resume := rtype.Resume{
+		Publications: []rtype.Publication{
+			{Title: "On *Servers* [draft]", URL: "https://example.com/paper"},
+			{Title: "snake_case <considered> harmful"},
+		},
+		Employment: []rtype.Employment{
+			{Title: "Staff_Engineer", Description: "Built **some** ((https://example.com/app app_one)) in C*."},
+		},
+	}
+
+	want := "- [On \\*Servers\\* \\[draft\\]](https://example.com/paper)\n" +
+		"- snake\\_case \\<considered\\> harmful\n" +
+		"Staff\\_Engineer: Built \\*\\*some\\*\\* [app\\_one](https://example.com/app) in C\\*."
+
+	for _, text := range []bool{true, false} {
+		var buf strings.Builder
+		if err := Render(&buf, resume, fsys, Options{Text: text, Markdown: true, Ext: ".tem"}); err != nil {
+			t.Errorf("unexpected error rendering Markdown (text=%t): %v", text, err)
+		} else if buf.String() != want {
+			t.Errorf("expected %q; got %q", want, buf.String())
+		}
+	}
+}
+
+func TestRenderExtra(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"index.tem": `{{ .Me.Name }} for {{ .Extra.company }}{{ if metaBool .Extra "draft" }} (draft){{ end }}` +
+			`{{ metaStr .Extra "missing" }} in {{ metaInt .Extra "year" }}`,
+	})
+	resume := rtype.Resume{Me: rtype.Me{Chosen: "Me"}}
+
+	table := []struct {
+		extra map[string]string
+		want  string
+	}{
+		{map[string]string{"company": "Foobiz", "draft": "true", "year": "2024"}, "Me for Foobiz (draft) in 2024"},
+		{map[string]string{"company": "Barco"}, "Me for Barco in 0"},
+	}
+
+	for _, e := range table {
+		var buf strings.Builder
+		if err := Render(&buf, resume, fsys, Options{Text: true, Ext: ".tem", Extra: e.extra}); err != nil {
+			t.Errorf("unexpected error rendering with %v: %v", e.extra, err)
+		} else if buf.String() != e.want {
+			t.Errorf("expected %q; got %q", e.want, buf.String())
+		}
+	}
+}
+
+func TestExecuteTimes(t *testing.T) {
+	defer func(f func() time.Time) { now = f }(now)
+	rendered := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return rendered }
+
+	fsys := mapFS(map[string]string{
+		"index.tem": `Data updated {{ date "2006-01" .SourceModTime }}, rendered {{ date "2006-01" .RenderedAt }}`,
+	})
+	r, err := New(fsys, Options{Text: true, Ext: ".tem"})
+	if err != nil {
+		t.Fatalf("unexpected error loading templates: %v", err)
+	}
+
+	table := []struct {
+		data Data
+		want string
+	}{
+		{Data{SourceModTime: time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)}, "Data updated 2024-03, rendered 2024-05"},
+		// Without a source file, the resume was updated when it was rendered.
+		{Data{}, "Data updated 2024-05, rendered 2024-05"},
+	}
+
+	for _, e := range table {
+		var buf strings.Builder
+		if err := r.Execute(&buf, e.data); err != nil {
+			t.Errorf("unexpected error executing: %v", err)
+		} else if buf.String() != e.want {
+			t.Errorf("expected %q; got %q", e.want, buf.String())
+		}
+	}
+}
+
+func TestNewMissingTemplate(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"index.tem":    `{{ template "contact" . }}`,
+		"partials.tem": `{{ define "contact" }}{{ .Me.Email }}{{ end }}{{ define "empty" }}{{ end }}`,
+	})
+
+	for _, text := range []bool{true, false} {
+		_, err := New(fsys, Options{Text: text, Ext: ".tem", Template: "signature"})
+		want := `no such template "signature"; available templates: contact, index.tem`
+		if err == nil || err.Error() != want {
+			t.Errorf("expected error %q (text=%t); got %v", want, text, err)
+		}
+
+		r, err := New(fsys, Options{Text: text, Ext: ".tem", Template: "contact"})
+		if err != nil {
+			t.Fatalf("unexpected error loading defined template (text=%t): %v", text, err)
+		}
+
+		var resume rtype.Resume
+		resume.Me.Email = "me@example.com"
+
+		var buf strings.Builder
+		if err = r.Render(&buf, resume); err != nil || buf.String() != "me@example.com" {
+			t.Errorf("Render = %q, %v; expected %q", buf.String(), err, "me@example.com")
+		}
+	}
+
+}
+
+func TestNewDefaultTemplate(t *testing.T) {
+	table := []struct {
+		files map[string]string
+		want  string // The main template, or the error if it begins with "!".
+	}{
+		{map[string]string{"index.tem": `Index`, "other.tem": `Other`}, "index.tem"},
+		{map[string]string{"resume.tem": `Resume`}, "resume.tem"},
+		{map[string]string{"resume.tem": `Resume`, "partials/head.tem": `Head`, "defs.tem": `{{ define "x" }}X{{ end }}`}, "resume.tem"},
+		{map[string]string{"a.tem": `A`, "b.tem": `B`}, "!no index.tem template to render by default; name one of: a.tem, b.tem"},
+		{map[string]string{"partials/head.tem": `Head`}, "!no index.tem template to render by default; name one of: partials/head.tem"},
+	}
+
+	for _, e := range table {
+		r, err := New(mapFS(e.files), Options{Text: true, Ext: ".tem"})
+		if strings.HasPrefix(e.want, "!") {
+			if err == nil || err.Error() != e.want[1:] {
+				t.Errorf("expected error %q loading %v; got %v", e.want[1:], e.files, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error loading %v: %v", e.files, err)
+		} else if r.Name() != e.want {
+			t.Errorf("expected main template %q loading %v; got %q", e.want, e.files, r.Name())
+		}
+	}
+}
+
+func TestNewLinkifyOptions(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"index.tem": `{{ linkify "<((https://example.com a))> [b](https://example.org)" }}`,
+	})
+
+	table := []struct {
+		opts Options
+		want string
+	}{
+		{Options{Ext: ".tem", NoLinkify: true}, `&lt;((https://example.com a))&gt; [b](https://example.org)`},
+		{Options{Ext: ".tem", Text: true, NoLinkify: true}, `<((https://example.com a))> [b](https://example.org)`},
+		{Options{Ext: ".tem", LinkPattern: regexp.MustCompile(`\[[^\]]*\]\([^)]*\)`)},
+			`&lt;((https://example.com a))&gt; <a href="https://example.org">b</a>`},
+	}
+
+	for _, e := range table {
+		var buf strings.Builder
+		if err := Render(&buf, rtype.Resume{}, fsys, e.opts); err != nil {
+			t.Errorf("unexpected error rendering: %v", err)
+		} else if buf.String() != e.want {
+			t.Errorf("expected %q; got %q", e.want, buf.String())
+		}
+	}
+}
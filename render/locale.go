@@ -0,0 +1,115 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nilium/resify/rtype"
+)
+
+// dateNames are the names of months and weekdays in a language, indexed by time.Month - 1 and time.Weekday.
+type dateNames struct {
+	months, shortMonths [12]string
+	days, shortDays     [7]string
+}
+
+// locales are the date names of the languages supported by dateLoc, by their ISO 639-1 codes. English isn't listed, since
+// time.Format already writes English names.
+var locales = map[string]*dateNames{
+	"de": {
+		months: [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober",
+			"November", "Dezember"},
+		shortMonths: [12]string{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sept.", "Okt.", "Nov.", "Dez."},
+		days:        [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		shortDays:   [7]string{"So.", "Mo.", "Di.", "Mi.", "Do.", "Fr.", "Sa."},
+	},
+	"fr": {
+		months: [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre",
+			"novembre", "décembre"},
+		shortMonths: [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.",
+			"déc."},
+		days:      [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		shortDays: [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+	},
+	"es": {
+		months: [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre",
+			"noviembre", "diciembre"},
+		shortMonths: [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+		days:        [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		shortDays:   [7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+	},
+}
+
+// localeLanguage returns the language of locale, such as "de" for "de", "de-AT", or "de_DE.UTF-8".
+func localeLanguage(locale string) string {
+	if i := strings.IndexAny(locale, "-_."); i >= 0 {
+		locale = locale[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(locale))
+}
+
+// knownLocale returns whether locale is empty or in a language supported by dateLoc.
+func knownLocale(locale string) bool {
+	lang := localeLanguage(locale)
+	_, ok := locales[lang]
+	return ok || lang == "" || lang == "en"
+}
+
+// nameChunks are the parts of a time layout that are month and weekday names, longest first, so that "January" isn't read
+// as "Jan" followed by "uary".
+var nameChunks = []string{"January", "Monday", "Jan", "Mon"}
+
+// formatLocal formats t using layout as time.Format does, but with month and weekday names from names. If names is nil,
+// the names are English.
+func formatLocal(t time.Time, layout string, names *dateNames) string {
+	if names == nil {
+		return t.Format(layout)
+	}
+
+	var b strings.Builder
+	for len(layout) > 0 {
+		i, chunk := len(layout), ""
+		for _, c := range nameChunks {
+			if j := strings.Index(layout, c); j >= 0 && (j < i || j == i && len(c) > len(chunk)) {
+				i, chunk = j, c
+			}
+		}
+		if i > 0 {
+			b.WriteString(t.Format(layout[:i]))
+		}
+		switch chunk {
+		case "January":
+			b.WriteString(names.months[t.Month()-1])
+		case "Jan":
+			b.WriteString(names.shortMonths[t.Month()-1])
+		case "Monday":
+			b.WriteString(names.days[t.Weekday()])
+		case "Mon":
+			b.WriteString(names.shortDays[t.Weekday()])
+		}
+		layout = layout[i+len(chunk):]
+	}
+	return b.String()
+}
+
+// dateLoc is formatDate with month and weekday names in the renderer's locale (see Options.Locale).
+func (r *Renderer) dateLoc(layout string, t interface{}) (string, error) {
+	switch t := t.(type) {
+	case time.Time:
+		if t.IsZero() {
+			return "", nil
+		}
+		return formatLocal(t, layout, r.dateNames), nil
+	case rtype.DateRange:
+		ends := make([]string, 0, 2)
+		for _, end := range []time.Time{t.From, t.To} {
+			if !end.IsZero() {
+				ends = append(ends, formatLocal(end, layout, r.dateNames))
+			}
+		}
+		return strings.Join(ends, " - "), nil
+	default:
+		return "", fmt.Errorf("cannot format %T as a date", t)
+	}
+}
@@ -0,0 +1,83 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nilium/resify/rtype"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestFormatLocal(t *testing.T) {
+	// A Thursday.
+	date := time.Date(2015, time.January, 15, 9, 30, 0, 0, time.UTC)
+
+	table := []struct {
+		locale, layout, want string
+	}{
+		{"", "January 2006", "January 2015"},
+		{"en-US", "Mon, Jan 2 2006", "Thu, Jan 15 2015"},
+		{"de", "January 2006", "Januar 2015"},
+		{"de-AT", "Monday, 2. January 2006", "Donnerstag, 15. Januar 2015"},
+		{"de_DE.UTF-8", "Mon 02.01.2006", "Do. 15.01.2015"},
+		{"fr", "2 January 2006 15:04", "15 janvier 2015 09:30"},
+		{"fr-CA", "Jan 2006", "janv. 2015"},
+		{"ES", "Monday 2 January", "jueves 15 enero"},
+		{"xx", "January 2006", "January 2015"},
+	}
+
+	for _, e := range table {
+		r, err := New(mapFS(map[string]string{"index.tem": ""}), Options{Ext: ".tem", Locale: e.locale})
+		if err != nil {
+			t.Fatalf("unexpected error creating renderer: %v", err)
+		}
+		if got, err := r.dateLoc(e.layout, date); err != nil || got != e.want {
+			t.Errorf("dateLoc(%q) in %q = %q, %v; want %q", e.layout, e.locale, got, err, e.want)
+		}
+	}
+}
+
+func TestDateLoc(t *testing.T) {
+	when, err := rtype.NewDateRange("2014-03", "2015-12")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var warnings []string
+	warnf := func(format string, args ...interface{}) { warnings = append(warnings, fmt.Sprintf(format, args...)) }
+	fsys := mapFS(map[string]string{"index.tem": `{{ dateLoc "January 2006" .When }}|{{ date "January 2006" .When }}`})
+
+	table := []struct {
+		locale, want string
+		warn         bool
+	}{
+		{"de", "März 2014 - Dezember 2015|March 2014 - December 2015", false},
+		{"", "March 2014 - December 2015|March 2014 - December 2015", false},
+		{"tlh", "March 2014 - December 2015|March 2014 - December 2015", true},
+	}
+
+	for _, e := range table {
+		warnings = nil
+		var buf strings.Builder
+		r, err := New(fsys, Options{Text: true, Ext: ".tem", Locale: e.locale, Warnf: warnf})
+		if err == nil {
+			err = r.set.ExecuteTemplate(&buf, r.Name(), struct{ When rtype.DateRange }{when})
+		}
+		if err != nil {
+			t.Errorf("unexpected error rendering in %q: %v", e.locale, err)
+		} else if buf.String() != e.want {
+			t.Errorf("rendered %q in %q; want %q", buf.String(), e.locale, e.want)
+		}
+		if (len(warnings) > 0) != e.warn {
+			t.Errorf("warnings in %q = %q; want warning: %t", e.locale, warnings, e.warn)
+		}
+	}
+
+	// Resume files are unaffected by the locale.
+	b, err := yaml.Marshal(when)
+	if want := "from: 2014-03\nto: 2015-12\n"; err != nil || string(b) != want {
+		t.Errorf("yaml.Marshal(%v) = %q, %v; want %q", when, b, err, want)
+	}
+}
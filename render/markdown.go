@@ -0,0 +1,126 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	htmlt "html/template"
+	"regexp"
+	"strconv"
+	"strings"
+
+	blackfriday "gopkg.in/russross/blackfriday.v2"
+)
+
+// markdownSource returns the Markdown held by v. This is either a plain string or HTML returned by another template function,
+// so that markdown can follow linkify in a pipeline.
+func markdownSource(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case htmlt.HTML:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// markdownEscaper escapes the characters that begin inline Markdown formatting: emphasis, code spans, links, and HTML.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	`*`, `\*`,
+	`_`, `\_`,
+	`[`, `\[`,
+	`]`, `\]`,
+	`<`, `\<`,
+	`>`, `\>`,
+)
+
+// markdownEscape escapes s so that it's read as literal text in Markdown output, rather than as inline formatting.
+func markdownEscape(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// markdownHTML renders v as Markdown to HTML.
+func markdownHTML(v interface{}) htmlt.HTML {
+	return htmlt.HTML(blackfriday.Run([]byte(markdownSource(v))))
+}
+
+var extraNewlines = regexp.MustCompile(`\n{3,}`)
+
+// markdownText renders v as Markdown to plain text. Emphasis and other inline formatting is dropped, blocks are separated by
+// blank lines, list items are prefixed with "-" or their number, and links are followed by their URL in parentheses (the
+// same as the default text link template) unless the link text is the URL. Raw HTML is dropped.
+func markdownText(v interface{}) string {
+	var buf bytes.Buffer
+	doc := blackfriday.New(blackfriday.WithExtensions(blackfriday.CommonExtensions)).Parse([]byte(markdownSource(v)))
+	doc.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		switch node.Type {
+		case blackfriday.Text, blackfriday.Code:
+			buf.Write(node.Literal)
+		case blackfriday.CodeBlock:
+			buf.Write(node.Literal)
+			buf.WriteString("\n\n")
+		case blackfriday.Softbreak, blackfriday.Hardbreak:
+			buf.WriteString("\n")
+		case blackfriday.TableRow:
+			if !entering {
+				buf.WriteString("\n")
+			}
+		case blackfriday.TableCell:
+			if !entering && node.Next != nil {
+				buf.WriteString("\t")
+			}
+		case blackfriday.Paragraph, blackfriday.Heading, blackfriday.BlockQuote, blackfriday.HorizontalRule, blackfriday.List,
+			blackfriday.Table:
+			if entering {
+				break
+			}
+			if node.Parent != nil && node.Parent.Type == blackfriday.Item {
+				if node.Type == blackfriday.Paragraph {
+					buf.WriteString("\n")
+				}
+			} else {
+				buf.WriteString("\n\n")
+			}
+		case blackfriday.Item:
+			if !entering {
+				break
+			}
+			depth := 0
+			for p := node.Parent; p != nil; p = p.Parent {
+				if p.Type == blackfriday.List {
+					depth++
+				}
+			}
+			if depth > 1 && buf.Len() > 0 && buf.Bytes()[buf.Len()-1] != '\n' {
+				buf.WriteString("\n")
+			}
+			buf.WriteString(strings.Repeat("  ", depth-1))
+			if node.ListFlags&blackfriday.ListTypeOrdered != 0 {
+				n := 1
+				for p := node.Prev; p != nil; p = p.Prev {
+					n++
+				}
+				buf.WriteString(strconv.Itoa(n) + ". ")
+			} else {
+				buf.WriteString("- ")
+			}
+		case blackfriday.Link:
+			if entering {
+				break
+			}
+			var text bytes.Buffer
+			for c := node.FirstChild; c != nil; c = c.Next {
+				text.Write(c.Literal)
+			}
+			if dest := string(node.LinkData.Destination); len(dest) > 0 && text.String() != dest &&
+				"mailto:"+text.String() != dest {
+				buf.WriteString(" (" + dest + ")")
+			}
+		}
+		return blackfriday.GoToNext
+	})
+
+	return strings.Trim(extraNewlines.ReplaceAllString(buf.String(), "\n\n"), whitespace)
+}
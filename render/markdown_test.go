@@ -0,0 +1,34 @@
+package render
+
+import (
+	htmlt "html/template"
+	"testing"
+)
+
+func TestMarkdownText(t *testing.T) {
+	table := []struct {
+		in, out string
+	}{
+		{"plain text", "plain text"},
+		{"some **bold** and _emphasized_ `code`", "some bold and emphasized code"},
+		{"# Heading\n\nParagraph one\ncontinued.\n\nParagraph two.", "Heading\n\nParagraph one\ncontinued.\n\nParagraph two."},
+		{"Did things:\n\n- *one*\n- two\n  - nested\n- three", "Did things:\n\n- one\n- two\n  - nested\n- three"},
+		{"1. first\n2. second", "1. first\n2. second"},
+		{"see [the site](https://example.com) or <https://example.org>", "see the site (https://example.com) or https://example.org"},
+		{"raw <b>html</b> dropped", "raw html dropped"},
+	}
+
+	for _, e := range table {
+		if got := markdownText(e.in); got != e.out {
+			t.Errorf("markdownText(%q) = %q; expected %q", e.in, got, e.out)
+		}
+	}
+}
+
+func TestMarkdownHTMLAfterLinkify(t *testing.T) {
+	in := htmlt.HTML(`**did** <a href="https://example.com">things</a> &amp; stuff`)
+	want := htmlt.HTML("<p><strong>did</strong> <a href=\"https://example.com\">things</a> &amp; stuff</p>\n")
+	if got := markdownHTML(in); got != want {
+		t.Errorf("markdownHTML(%q) = %q; expected %q", in, got, want)
+	}
+}
@@ -0,0 +1,385 @@
+// Package render renders resumes using text or HTML templates loaded from a filesystem. It's the rendering used by the resify
+// command, for programs that want to render resumes with their own templates, such as templates embedded in the program
+// with go:embed:
+//
+//  //go:embed templates
+//  var templates embed.FS
+//
+//  func writeResume(w io.Writer, resume rtype.Resume) error {
+//      sub, err := fs.Sub(templates, "templates")
+//      if err != nil {
+//          return err
+//      }
+//      return render.Render(w, resume, sub, render.Options{Ext: ".tem"})
+//  }
+//
+// Templates have the same functions available to them as templates rendered by resify (see the resify command for a
+// list). Files read by the embed and dataURI functions are read from the same filesystem as the templates.
+package render // import "github.com/nilium/resify/render"
+
+import (
+	"fmt"
+	htmlt "html/template"
+	"io"
+	"io/fs"
+	"regexp"
+	"strings"
+	textt "text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/nilium/resify/linkify"
+	"github.com/nilium/resify/rtype"
+)
+
+const whitespace = "\r\n\t "
+
+type template interface {
+	ExecuteTemplate(io.Writer, string, interface{}) error
+}
+
+func nopstring(s string) string { return s }
+
+// Default "link" templates, used when no "link" template is defined by the loaded templates.
+const (
+	DefaultHTMLLink     = `<a href="{{ .URL }}">{{ .Label }}</a>`
+	DefaultTextLink     = `{{ .Label }} ({{ .URL }})`
+	DefaultMarkdownLink = `[{{ escape .Label }}]({{ .URL }})`
+)
+
+// Options controls how templates are loaded and rendered.
+type Options struct {
+	// Text is whether templates are text templates. If false, templates are HTML templates and their output is escaped
+	// according to its context.
+	Text bool
+
+	// Markdown is whether text templates produce Markdown. Text given to linkify and link is escaped so that it isn't read
+	// as Markdown formatting, the default "link" template writes Markdown links, and the markdown function returns its
+	// input unchanged. Markdown implies Text.
+	Markdown bool
+
+	// Ext is the extension of template files, including its leading dot (e.g., ".tem"). Only files ending in Ext are loaded
+	// as templates.
+	Ext string
+
+	// Template is the name of the main template to execute, which may be a template file or a template defined within one.
+	// If empty, it's "index" followed by Ext or, if there's no such template and only one top-level template file, that
+	// file. If no template is defined by that name, Ext is appended to it. New returns an error listing the templates
+	// available if neither is defined.
+	Template string
+
+	// TemplateSource, if not empty, is the source of the main template, which is added to the loaded templates as
+	// MainTemplateName. Template is ignored, and the templates filesystem need not have any templates.
+	TemplateSource string
+
+	// Delims are the left and right action delimiters. Empty delimiters are the defaults, "{{" and "}}".
+	Delims [2]string
+
+	// Autolink is whether linkify also links bare URLs and email addresses.
+	Autolink bool
+
+	// NoLinkify is whether linkify leaves links alone, only escaping the text given to it.
+	NoLinkify bool
+
+	// LinkPattern, if not nil, matches the links converted by linkify instead of the default pattern (see
+	// linkify.Linker.Pattern).
+	LinkPattern *regexp.Regexp
+
+	// Extra holds values that aren't part of the resume, such as the date a resume was generated, which templates can read
+	// as .Extra (see Data).
+	Extra map[string]string
+
+	// Locale is the locale, such as "de" or "fr-CA", whose month and weekday names are written by the dateLoc function. Only
+	// its language is used, and only English (the default), German, French, and Spanish are supported. Unknown languages
+	// are written in English, after calling Warnf.
+	Locale string
+
+	// IgnoreMissingEmbeds is whether the embed function returns an empty string, after calling Warnf, when the file given
+	// to it doesn't exist. Otherwise, rendering fails with a MissingEmbedError.
+	IgnoreMissingEmbeds bool
+
+	// Warnf, if not nil, is called with messages about problems that don't stop rendering, such as missing embeds.
+	Warnf func(format string, args ...interface{})
+
+	// Debugf, if not nil, is called with messages about templates loaded, files embedded, and links rendered.
+	Debugf func(format string, args ...interface{})
+}
+
+// Data is what templates are executed with: the resume being rendered, whose fields and methods templates use as they
+// would the resume's own, and any extra values given by Options.Extra.
+type Data struct {
+	rtype.Resume
+	Extra map[string]string
+
+	// SourceModTime is when the resume's file was last modified. It's the same as RenderedAt if the resume wasn't read from
+	// a file, such as when it's read from stdin.
+	SourceModTime time.Time
+
+	// RenderedAt is when the resume was rendered.
+	RenderedAt time.Time
+}
+
+// now returns the current time for Data.RenderedAt.
+var now = time.Now
+
+// Renderer renders resumes with a set of loaded templates. Files read by the templates' embed and dataURI functions are
+// read from the same filesystem as the templates and cached, so a Renderer should be created again to see changes to
+// either. A Renderer is safe for concurrent use.
+type Renderer struct {
+	set    template
+	main   string
+	escape func(string) string
+	fsys   fs.FS
+	files  fileCache
+	linker linkify.Linker
+	front  FrontMatter
+	extra  map[string]string
+	warnf  func(string, ...interface{})
+	debugf func(string, ...interface{})
+
+	dateNames           *dateNames // The names written by dateLoc, or nil for English.
+	ignoreMissingEmbeds bool
+}
+
+// New loads the templates in fsys as text or HTML templates, according to opts, and returns a Renderer for them.
+func New(fsys fs.FS, opts Options) (*Renderer, error) {
+	r := &Renderer{
+		fsys:   fsys,
+		extra:  opts.Extra,
+		warnf:  opts.Warnf,
+		debugf: opts.Debugf,
+
+		ignoreMissingEmbeds: opts.IgnoreMissingEmbeds,
+	}
+	if r.warnf == nil {
+		r.warnf = func(string, ...interface{}) {}
+	}
+	if r.debugf == nil {
+		r.debugf = func(string, ...interface{}) {}
+	}
+	if !knownLocale(opts.Locale) {
+		r.warnf("unknown locale %q, so dates are written in English", opts.Locale)
+	}
+	r.dateNames = locales[localeLanguage(opts.Locale)]
+
+	fromSource := opts.TemplateSource != ""
+	load := func(kind string, parse func(name, src string) error, defined func(string) bool, trees func() []*parse.Tree) (string, error) {
+		fronts := map[string]FrontMatter{}
+		err := loadTemplates(fsys, opts.Ext, opts.Delims, func(name, src string, front FrontMatter) error {
+			r.debugf("loading template %s", name)
+			fronts[name] = front
+			return parse(name, src)
+		})
+		if err != nil && !(fromSource && isMissingTemplates(err)) {
+			return "", fmt.Errorf("error parsing template as %s: %w", kind, err)
+		}
+
+		if !fromSource {
+			name := resolveTemplate(opts.Template, opts.Ext, defined)
+			if !defined(name) {
+				available := executableNames(trees())
+				if opts.Template != "" {
+					return "", noSuchTemplateError{name: name, available: available}
+				}
+
+				// Without an index template, a lone top-level template file is the main template.
+				var files []string
+				for _, n := range available {
+					if _, ok := fronts[n]; ok && !strings.Contains(n, "/") {
+						files = append(files, n)
+					}
+				}
+				if len(files) != 1 {
+					return "", noSuchTemplateError{name: name, available: available, defaulted: true}
+				}
+				name = files[0]
+				r.debugf("no %s template, so using %s", "index"+opts.Ext, name)
+			}
+			r.front = fronts[name]
+			return name, nil
+		}
+
+		r.debugf("loading template %s", MainTemplateName)
+		front, src, err := splitFrontMatter(opts.TemplateSource)
+		if err != nil {
+			return "", fmt.Errorf("%s: cannot parse front matter: %w", MainTemplateName, err)
+		}
+		if err = parse(MainTemplateName, src); err != nil {
+			return "", fmt.Errorf("error parsing template as %s: %w", kind, err)
+		}
+		r.front = front
+		return MainTemplateName, nil
+	}
+
+	var err error
+	if opts.Text || opts.Markdown {
+		escape, defaultLink := nopstring, DefaultTextLink
+		tx := textt.New("root").Delims(opts.Delims[0], opts.Delims[1]).Funcs(textFuncs).Funcs(r.textFuncs())
+		if opts.Markdown {
+			escape, defaultLink = markdownEscape, DefaultMarkdownLink
+			tx.Funcs(markdownFuncs)
+		}
+		r.main, err = load("text",
+			func(name, src string) error { _, err := tx.New(name).Parse(src); return err },
+			func(name string) bool { return tx.Lookup(name) != nil },
+			func() (trees []*parse.Tree) {
+				for _, t := range tx.Templates() {
+					trees = append(trees, t.Tree)
+				}
+				return trees
+			})
+		if err != nil {
+			return nil, err
+		}
+
+		if tx.Lookup("link") == nil {
+			textt.Must(tx.New("link").Delims("", "").Parse(defaultLink))
+		}
+
+		r.set, r.escape = tx, escape
+	} else {
+		tx := htmlt.New("root").Delims(opts.Delims[0], opts.Delims[1]).Funcs(htmlFuncs).Funcs(r.htmlFuncs())
+		r.main, err = load("html",
+			func(name, src string) error { _, err := tx.New(name).Parse(src); return err },
+			func(name string) bool { return tx.Lookup(name) != nil },
+			func() (trees []*parse.Tree) {
+				for _, t := range tx.Templates() {
+					trees = append(trees, t.Tree)
+				}
+				return trees
+			})
+		if err != nil {
+			return nil, err
+		}
+
+		if tx.Lookup("link") == nil {
+			htmlt.Must(tx.New("link").Delims("", "").Parse(DefaultHTMLLink))
+		}
+
+		r.set, r.escape = tx, htmlt.HTMLEscapeString
+	}
+
+	r.linker = linkify.Linker{
+		Template: r.set,
+		Escape:   r.escape,
+		Autolink: opts.Autolink,
+		Disabled: opts.NoLinkify,
+		Pattern:  opts.LinkPattern,
+		Warnf:    r.warnf,
+		Debugf:   r.debugf,
+	}
+	return r, nil
+}
+
+// textFuncs returns the functions available to text templates that are bound to r.
+func (r *Renderer) textFuncs() textt.FuncMap {
+	return textt.FuncMap{
+		"embed":   r.embed,
+		"dataURI": r.dataURI,
+		"dateLoc": r.dateLoc,
+		"linkify": r.Linkify,
+		"link":    r.link,
+
+		"photo":      nopstring,
+		"srcset":     func(images ...string) (string, error) { return r.srcset(false, images) },
+		"dataSrcset": func(images ...string) (string, error) { return r.srcset(true, images) },
+	}
+}
+
+// htmlFuncs returns the functions available to HTML templates that are bound to r.
+func (r *Renderer) htmlFuncs() htmlt.FuncMap {
+	return htmlt.FuncMap{
+		"embed":   r.embed,
+		"dateLoc": r.dateLoc,
+		"dataURI": func(path string) (htmlt.URL, error) { s, err := r.dataURI(path); return htmlt.URL(s), err },
+		"linkify": func(s string) htmlt.HTML { return htmlt.HTML(r.Linkify(s)) },
+		"link":    func(url string, label ...string) htmlt.HTML { return htmlt.HTML(r.link(url, label...)) },
+
+		"photo": func(src string) (htmlt.URL, error) { s, err := r.photo(src); return htmlt.URL(s), err },
+		"srcset": func(images ...string) (htmlt.Srcset, error) {
+			s, err := r.srcset(false, images)
+			return htmlt.Srcset(s), err
+		},
+		"dataSrcset": func(images ...string) (htmlt.Srcset, error) {
+			s, err := r.srcset(true, images)
+			return htmlt.Srcset(s), err
+		},
+	}
+}
+
+// Name returns the name of the main template executed by Render.
+func (r *Renderer) Name() string {
+	return r.main
+}
+
+// FrontMatter returns the front matter of the main template. It's empty if the main template has none or is defined by a
+// define action instead of a file.
+func (r *Renderer) FrontMatter() FrontMatter {
+	return r.front
+}
+
+// Linkify converts links in s to links rendered by the renderer's "link" template, escaping the text around them for the
+// renderer's output (see linkify.Linker.Linkify).
+func (r *Renderer) Linkify(s string) string {
+	return r.linker.Linkify(s)
+}
+
+// RenderLink renders a single link of the form ((URL label)) or [label](URL) with the renderer's "link" template (see
+// linkify.Linker.RenderLink). The result is not escaped.
+func (r *Renderer) RenderLink(p string) (string, error) {
+	return r.linker.RenderLink(p)
+}
+
+// link renders a link to rawURL labeled by the optional label with the "link" template. If rawURL is empty or the link
+// cannot be rendered, the escaped label is returned instead.
+func (r *Renderer) link(rawURL string, label ...string) string {
+	text := strings.Join(label, " ")
+	if strings.Trim(rawURL, whitespace) == "" {
+		return r.escape(text)
+	}
+
+	l, err := linkify.New(rawURL, text)
+	if err != nil {
+		r.warnf("error parsing link %q: %v", rawURL, err)
+		return r.escape(text)
+	}
+	if s, err := r.linker.Format(l); err == nil {
+		return s
+	}
+	return r.escape(l.Label)
+}
+
+// Render renders resume to w using the main template, along with the renderer's extra values (see Data). The resume is
+// treated as if it weren't read from a file, so its SourceModTime is the time it's rendered.
+func (r *Renderer) Render(w io.Writer, resume rtype.Resume) error {
+	return r.Execute(w, Data{Resume: resume})
+}
+
+// Execute renders data to w using the main template. If data has no extra values, it's given the renderer's. A zero
+// RenderedAt is set to the current time, and a zero SourceModTime to RenderedAt. If a template embeds a file that doesn't
+// exist, the error returned is a MissingEmbedError naming the template that embedded it.
+func (r *Renderer) Execute(w io.Writer, data Data) error {
+	if data.Extra == nil {
+		data.Extra = r.extra
+	}
+	if data.RenderedAt.IsZero() {
+		data.RenderedAt = now()
+	}
+	if data.SourceModTime.IsZero() {
+		data.SourceModTime = data.RenderedAt
+	}
+	err := r.set.ExecuteTemplate(w, r.main, data)
+	if missing := missingEmbed(err); missing != nil {
+		return missing
+	}
+	return err
+}
+
+// Render loads the templates in fsys according to opts and renders resume to w with them.
+func Render(w io.Writer, resume rtype.Resume, fsys fs.FS, opts Options) error {
+	r, err := New(fsys, opts)
+	if err != nil {
+		return err
+	}
+	return r.Render(w, resume)
+}
@@ -1,9 +1,16 @@
 package rtype
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
+
+	yaml "gopkg.in/yaml.v2"
 )
 
 var errUndefined = errors.New("field undefined")
@@ -24,7 +31,19 @@ func (d dateLayouts) Parse(value string) (t time.Time, layout string, err error)
 	return time.Time{}, "", err
 }
 
+// dateLayout is the layout used to format times that weren't parsed with a layout.
+const dateLayout = "2006-01-02"
+
+// Layouts with zone abbreviations (MST) only know the offset of UTC and the local time zone; other abbreviations are kept,
+// but treated as UTC. Layouts with numeric offsets (-0700) always keep the exact time.
+//
+// ISO-style layouts come first so they take precedence. The month name and slash layouts after them accept dates as they're
+// often written by hand, such as "Aug 2015", "August 2015", "08/2015", "2015/08", and "08/31/2015". Numeric dates with
+// slashes are always read month first, so "01/02/2015" is January 2nd, never February 1st.
 var layouts dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04 -0700",
 	"2006-01-02 15:04:05 MST",
 	"2006-01-02 15:04:05",
 	"2006-01-02 15:04 MST",
@@ -32,62 +51,544 @@ var layouts dateLayouts = []string{
 	"2006-01-02",
 	"2006-01",
 	"2006",
+	"Jan 2006",
+	"January 2006",
+	"1/2006",
+	"2006/1",
+	"1/2/2006",
+}
+
+// canonicalLayouts maps the month name and slash layouts to the ISO-style layout with the same precision. Dates parsed with
+// one of them are written in its canonical layout, so they're unambiguous once written back out.
+var canonicalLayouts = map[string]string{
+	"Jan 2006":     "2006-01",
+	"January 2006": "2006-01",
+	"1/2006":       "2006-01",
+	"2006/1":       "2006-01",
+	"1/2/2006":     "2006-01-02",
+}
+
+// Meta is the inline metadata attached to most types. In YAML its keys sit alongside the fields of the type that owns it.
+// When marshalled to JSON, it appears under a "meta" key instead, since JSON has no notion of inline maps.
+// In both YAML and JSON, its keys are written in sorted order, after the fields of the type that owns it in YAML.
+type Meta map[string]interface{}
+
+// MarshalJSON converts any nested YAML mappings held by m into string-keyed maps before encoding m as JSON. Nested maps
+// decoded from YAML use interface{} keys, which encoding/json refuses.
+func (m Meta) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(jsonValue(map[string]interface{}(m)))
+}
+
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = jsonValue(e)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = jsonValue(e)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = jsonValue(e)
+		}
+		return s
+	default:
+		return v
+	}
 }
 
 type Resume struct {
-	Me         Me           `yaml:"me"`
-	Profiles   Profiles     `yaml:"profiles"`
-	Employment []Employment `yaml:"work,omitempty"`
-	Education  []Education  `yaml:"education,omitempty"`
+	Me           Me            `yaml:"me" json:"me"`
+	Profiles     Profiles      `yaml:"profiles" json:"profiles"`
+	Summary      string        `yaml:"summary,omitempty" json:"summary,omitempty"`
+	Employment   []Employment  `yaml:"work,omitempty" json:"work,omitempty"`
+	Education    []Education   `yaml:"education,omitempty" json:"education,omitempty"`
+	Awards       []Award       `yaml:"awards,omitempty" json:"awards,omitempty"`
+	Publications []Publication `yaml:"publications,omitempty" json:"publications,omitempty"`
+	References   []Reference   `yaml:"references,omitempty" json:"references,omitempty"`
+
+	// Include lists other resume files to merge into this one. It's up to the reader of the resume to load and merge them
+	// (see Merge) and clear Include.
+	Include []string `yaml:"include,omitempty" json:"-"`
+
+	Meta Meta `yaml:",inline" json:"meta,omitempty"`
+}
+
+// Merge merges other into r. Employment, education, award, publication, and reference entries in other are appended to
+// those in r. Profiles, Me fields, the summary, and metadata in other are only used where r doesn't already have them,
+// and profile ordering from other is appended to r's. The Include field of other is ignored.
+func (r *Resume) Merge(other Resume) {
+	mergeString := func(dst *string, src string) {
+		if len(*dst) == 0 {
+			*dst = src
+		}
+	}
+
+	if len(r.Me.Order) == 0 {
+		r.Me.Order = other.Me.Order
+	}
+	mergeString(&r.Me.Chosen, other.Me.Chosen)
+	mergeString(&r.Me.Phone, other.Me.Phone)
+	mergeString(&r.Me.Email, other.Me.Email)
+	mergeString(&r.Me.Photo, other.Me.Photo)
+	r.Me.Meta = mergeMeta(r.Me.Meta, other.Me.Meta)
+	mergeString(&r.Summary, other.Summary)
+
+	r.Profiles.Order = append(r.Profiles.Order, other.Profiles.Order...)
+	for k, p := range other.Profiles.Profile {
+		if _, ok := r.Profiles.Profile[k]; ok {
+			continue
+		}
+		if r.Profiles.Profile == nil {
+			r.Profiles.Profile = map[string]Profile{}
+		}
+		r.Profiles.Profile[k] = p
+	}
+
+	r.Employment = append(r.Employment, other.Employment...)
+	r.Education = append(r.Education, other.Education...)
+	r.Awards = append(r.Awards, other.Awards...)
+	r.Publications = append(r.Publications, other.Publications...)
+	r.References = append(r.References, other.References...)
+	r.Meta = mergeMeta(r.Meta, other.Meta)
+}
+
+// Sections are the names of the sections of a resume that can be omitted with Omit. They're the sections' YAML keys.
+var Sections = []string{"me", "profiles", "work", "education", "awards", "publications", "references"}
+
+// Omit clears the section of r named by its YAML key (see Sections), such as "references". It returns an error if there is
+// no section by that name.
+func (r *Resume) Omit(section string) error {
+	switch section {
+	case "me":
+		r.Me = Me{}
+	case "profiles":
+		r.Profiles = Profiles{}
+	case "work":
+		r.Employment = nil
+	case "education":
+		r.Education = nil
+	case "awards":
+		r.Awards = nil
+	case "publications":
+		r.Publications = nil
+	case "references":
+		r.References = nil
+	default:
+		return fmt.Errorf("unknown section %q; must be one of %s", section, strings.Join(Sections, ", "))
+	}
+	return nil
+}
+
+// TagFilter selects the entries of a resume's list sections by their tags (see Resume.FilterTags). Tags are compared
+// without regard to case.
+type TagFilter struct {
+	Include    []string // If not empty, tagged entries are only kept if they have at least one of these tags.
+	Exclude    []string // Entries with any of these tags are dropped, even if they also have an included tag.
+	TaggedOnly bool     // Whether entries without tags are dropped. Otherwise, they're always kept.
+}
+
+// Keep returns whether an entry with tags is kept by f. An excluded tag takes precedence over everything else, followed by
+// TaggedOnly for untagged entries and then by Include for tagged ones.
+func (f TagFilter) Keep(tags []string) bool {
+	switch {
+	case hasAnyTag(tags, f.Exclude):
+		return false
+	case len(tags) == 0:
+		return !f.TaggedOnly
+	case len(f.Include) > 0:
+		return hasAnyTag(tags, f.Include)
+	}
+	return true
+}
+
+// IsZero returns whether f keeps every entry.
+func (f TagFilter) IsZero() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0 && !f.TaggedOnly
+}
+
+func hasAnyTag(tags, want []string) bool {
+	for _, t := range tags {
+		for _, a := range want {
+			if strings.EqualFold(t, a) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FilterTags removes the work, education, award, publication, and reference entries of r that aren't kept by f (see
+// TagFilter.Keep). The order of the remaining entries is unchanged.
+func (r *Resume) FilterTags(f TagFilter) {
+	if f.IsZero() {
+		return
+	}
+
+	n := 0
+	for _, e := range r.Employment {
+		if f.Keep(e.Tags) {
+			r.Employment[n], n = e, n+1
+		}
+	}
+	r.Employment = r.Employment[:n]
+
+	n = 0
+	for _, e := range r.Education {
+		if f.Keep(e.Tags) {
+			r.Education[n], n = e, n+1
+		}
+	}
+	r.Education = r.Education[:n]
+
+	n = 0
+	for _, e := range r.Awards {
+		if f.Keep(e.Tags) {
+			r.Awards[n], n = e, n+1
+		}
+	}
+	r.Awards = r.Awards[:n]
+
+	n = 0
+	for _, e := range r.Publications {
+		if f.Keep(e.Tags) {
+			r.Publications[n], n = e, n+1
+		}
+	}
+	r.Publications = r.Publications[:n]
+
+	n = 0
+	for _, e := range r.References {
+		if f.Keep(e.Tags) {
+			r.References[n], n = e, n+1
+		}
+	}
+	r.References = r.References[:n]
+}
+
+// Normalize fills fields of r that are empty but can be derived from others, so that templates can rely on them having
+// values. The fields derived are:
+//
+//  Me.Order: ["chosen"], so that Me.Name is the chosen name.
+//  Summary: the "statement" metadata key, if it's a string, as written by resume files from before Summary existed.
+//  Profile labels: the host of the profile's URL, without any port.
+//  Employment and education Where.Place: the place's Line, if it has structured city, region, postal, or country fields.
+//
+// Fields that already have values are never changed, so normalizing a resume again has no effect.
+func (r *Resume) Normalize() {
+	if len(r.Me.Order) == 0 {
+		r.Me.Order = []string{"chosen"}
+	}
+
+	if statement, ok := r.Meta["statement"].(string); ok && len(r.Summary) == 0 {
+		r.Summary = statement
+	}
+
+	for k, p := range r.Profiles.Profile {
+		if len(p.Label) > 0 {
+			continue
+		}
+		if u, err := url.Parse(p.URL); err == nil && len(u.Hostname()) > 0 {
+			p.Label = u.Hostname()
+			r.Profiles.Profile[k] = p
+		}
+	}
 
-	Meta map[string]interface{} `yaml:",inline"`
+	for i := range r.Employment {
+		r.Employment[i].Where.normalize()
+	}
+	for i := range r.Education {
+		r.Education[i].Where.normalize()
+	}
+}
+
+// mergeMeta returns dst with any keys from src that it doesn't already have.
+func mergeMeta(dst, src Meta) Meta {
+	for k, v := range src {
+		if _, ok := dst[k]; ok {
+			continue
+		}
+		if dst == nil {
+			dst = Meta{}
+		}
+		dst[k] = v
+	}
+	return dst
 }
 
 type Me struct {
-	Order  []string `yaml:"ordered,flow"`
-	Chosen string   `yaml:"chosen"`
-	Phone  string   `yaml:"phone"`
-	Email  string   `yaml:"email"`
+	Order  []string `yaml:"ordered,flow" json:"ordered"`
+	Chosen string   `yaml:"chosen" json:"chosen"`
+	Phone  string   `yaml:"phone" json:"phone"`
+	Email  string   `yaml:"email" json:"email"`
+
+	// Photo is a photo of the resume's owner, either as an absolute http(s) URL or a path relative to the templates
+	// directory.
+	Photo string `yaml:"photo,omitempty" json:"photo,omitempty"`
+
+	Meta Meta `yaml:",inline" json:"meta,omitempty"`
+}
+
+// PhotoIsURL returns whether m.Photo is an absolute http(s) URL, rather than a path.
+func (m Me) PhotoIsURL() bool {
+	u, err := url.Parse(m.Photo)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// Name returns the name described by m's Order field. Each entry in Order names either a field of Me or a key in its Meta,
+// and the non-empty values of those are joined with spaces. If Order is empty or names nothing with a value, Chosen is
+// returned.
+func (m Me) Name() string {
+	parts := make([]string, 0, len(m.Order))
+	for _, key := range m.Order {
+		if v := m.field(key); len(v) > 0 {
+			parts = append(parts, v)
+		}
+	}
 
-	Meta map[string]interface{} `yaml:",inline"`
+	if len(parts) == 0 {
+		return m.Chosen
+	}
+	return strings.Join(parts, " ")
+}
+
+// NameValue is a value of Me along with the key naming it in Me's Order field.
+type NameValue struct {
+	Name  string
+	Value string
+}
+
+// OrderedFields returns the values named by m's Order field, in that order, such as for rendering a contact block. Each
+// key is resolved the same as for Name, to a field of Me or else a key in its Meta. Keys that name nothing or an empty value
+// are skipped, as are keys already listed. Unlike Name, Chosen isn't used if nothing is found, so the result may be empty.
+func (m Me) OrderedFields() []NameValue {
+	fields := make([]NameValue, 0, len(m.Order))
+	seen := make(map[string]bool, len(m.Order))
+	for _, key := range m.Order {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if v := m.field(key); len(v) > 0 {
+			fields = append(fields, NameValue{Name: key, Value: v})
+		}
+	}
+	return fields
+}
+
+// field returns the value of the field or metadata of m named by key. Fields are matched by name, ignoring case, and take
+// precedence over metadata. Metadata is matched by key exactly, then by the lower-case key. If nothing matches, the result
+// is empty.
+func (m Me) field(key string) string {
+	switch strings.ToLower(key) {
+	case "chosen":
+		return m.Chosen
+	case "phone":
+		return m.Phone
+	case "email":
+		return m.Email
+	case "photo":
+		return m.Photo
+	}
+
+	v, ok := m.Meta[key]
+	if !ok {
+		v, ok = m.Meta[strings.ToLower(key)]
+	}
+	if !ok || v == nil {
+		return ""
+	}
+	return strings.TrimSpace(fmt.Sprint(v))
 }
 
 type Profiles struct {
-	Order   []string           `yaml:".order,flow"`
-	Profile map[string]Profile `yaml:",inline"`
+	Order   []string           `yaml:".order,flow" json:"order,omitempty"`
+	Profile map[string]Profile `yaml:",inline" json:"profiles,omitempty"`
+}
+
+// NamedProfile is a Profile along with the key it was given under profiles.
+type NamedProfile struct {
+	Key string
+	Profile
+}
+
+// Ordered returns the profiles in p in the order given by its Order field. Profiles not named in Order follow those that are,
+// sorted by key. Keys in Order that don't name a profile are skipped.
+func (p Profiles) Ordered() []NamedProfile {
+	ordered := make([]NamedProfile, 0, len(p.Profile))
+	seen := make(map[string]bool, len(p.Profile))
+	for _, k := range p.Order {
+		prof, ok := p.Profile[k]
+		if !ok || seen[k] {
+			continue
+		}
+		seen[k] = true
+		ordered = append(ordered, NamedProfile{Key: k, Profile: prof})
+	}
+
+	rest := make([]string, 0, len(p.Profile)-len(ordered))
+	for k := range p.Profile {
+		if !seen[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	for _, k := range rest {
+		ordered = append(ordered, NamedProfile{Key: k, Profile: p.Profile[k]})
+	}
+
+	return ordered
 }
 
 type Profile struct {
-	URL   string `yaml:"url,omitempty"`
-	Label string `yaml:"label,omitempty"`
+	URL   string `yaml:"url,omitempty" json:"url,omitempty"`
+	Label string `yaml:"label,omitempty" json:"label,omitempty"`
 
-	Meta map[string]interface{} `yaml:",inline"`
+	Meta Meta `yaml:",inline" json:"meta,omitempty"`
 }
 
-type Employment struct {
-	Title       string    `yaml:"title"`
-	When        DateRange `yaml:"when"`
-	Where       Place     `yaml:"where"`
-	Description string    `yaml:"desc,omitempty"`
+// UnmarshalYAML decodes a profile and normalizes its URL (see NormalizeURL).
+func (p *Profile) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type profile Profile
+	if err := unmarshal((*profile)(p)); err != nil {
+		return err
+	}
+	p.URL = NormalizeURL(p.URL)
+	return nil
+}
+
+// hostLike matches the start of a URL without a scheme that begins with a domain name, such as "github.com/me".
+var hostLike = regexp.MustCompile(`^[A-Za-z0-9-]+(\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}(/|$)`)
 
-	Meta map[string]interface{} `yaml:",inline"`
+// NormalizeURL returns rawURL with "https:" prepended if it has no scheme but begins with a host, as in "github.com/me" or
+// "//github.com/me". Other URLs, including those that are already absolute and those that cannot be parsed, are returned
+// unchanged.
+func NormalizeURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "" {
+		return rawURL
+	}
+
+	switch {
+	case u.Host != "":
+		return "https:" + rawURL
+	case hostLike.MatchString(rawURL):
+		return "https://" + rawURL
+	}
+	return rawURL
+}
+
+// Employment is a job held. Its Description is freeform text, while its Highlights, if any, are discrete points, such as
+// for a bulleted list. Either may be given without the other.
+type Employment struct {
+	Title       string    `yaml:"title" json:"title"`
+	When        DateRange `yaml:"when" json:"when"`
+	Where       Place     `yaml:"where" json:"where"`
+	Description string    `yaml:"desc,omitempty" json:"desc,omitempty"`
+	Highlights  []string  `yaml:"highlights,omitempty" json:"highlights,omitempty"`
+	Tags        []string  `yaml:"tags,omitempty" json:"tags,omitempty"`
+
+	Meta Meta `yaml:",inline" json:"meta,omitempty"`
 }
 
+// Education is a school attended. Like an employment entry's, its Highlights are discrete points complementing or
+// replacing its Description.
 type Education struct {
-	Where       Place     `yaml:"where"`
-	When        DateRange `yaml:"when"`
-	Received    string    `yaml:"received,omitempty"`
-	Fields      []string  `yaml:"fields,omitempty"`
-	Description string    `yaml:"desc,omitempty"`
+	Where       Place     `yaml:"where" json:"where"`
+	When        DateRange `yaml:"when" json:"when"`
+	Received    string    `yaml:"received,omitempty" json:"received,omitempty"`
+	Fields      []string  `yaml:"fields,omitempty" json:"fields,omitempty"`
+	Description string    `yaml:"desc,omitempty" json:"desc,omitempty"`
+	Highlights  []string  `yaml:"highlights,omitempty" json:"highlights,omitempty"`
+	Tags        []string  `yaml:"tags,omitempty" json:"tags,omitempty"`
+
+	Meta Meta `yaml:",inline" json:"meta,omitempty"`
+}
+
+// Award is an award or honor. Its Date is usually a single date, given as a string instead of a from/to mapping (see
+// DateRange), but may be a range.
+type Award struct {
+	Title   string    `yaml:"title" json:"title"`
+	Awarder string    `yaml:"awarder,omitempty" json:"awarder,omitempty"`
+	Date    DateRange `yaml:"date" json:"date"`
+	Summary string    `yaml:"summary,omitempty" json:"summary,omitempty"`
+	Tags    []string  `yaml:"tags,omitempty" json:"tags,omitempty"`
+
+	Meta Meta `yaml:",inline" json:"meta,omitempty"`
+}
+
+// Publication is a published work, such as a paper, article, or book. Like an award's, its Date is usually a single date.
+type Publication struct {
+	Title     string    `yaml:"title" json:"title"`
+	Publisher string    `yaml:"publisher,omitempty" json:"publisher,omitempty"`
+	Date      DateRange `yaml:"date" json:"date"`
+	URL       string    `yaml:"url,omitempty" json:"url,omitempty"`
+	Summary   string    `yaml:"summary,omitempty" json:"summary,omitempty"`
+	Tags      []string  `yaml:"tags,omitempty" json:"tags,omitempty"`
+
+	Meta Meta `yaml:",inline" json:"meta,omitempty"`
+}
+
+// Reference is someone who can vouch for the resume's owner. References are often kept out of rendered resumes, so they
+// can be omitted when reading a resume (see Omit).
+type Reference struct {
+	Name         string   `yaml:"name" json:"name"`
+	Relationship string   `yaml:"relationship,omitempty" json:"relationship,omitempty"`
+	Contact      string   `yaml:"contact,omitempty" json:"contact,omitempty"`
+	Note         string   `yaml:"note,omitempty" json:"note,omitempty"`
+	Tags         []string `yaml:"tags,omitempty" json:"tags,omitempty"`
 
-	Meta map[string]interface{} `yaml:",inline"`
+	Meta Meta `yaml:",inline" json:"meta,omitempty"`
 }
 
 type Place struct {
-	Name  string `yaml:"name,omitempty"`
-	Place string `yaml:"place,omitempty"`
+	Name  string `yaml:"name,omitempty" json:"name,omitempty"`
+	Place string `yaml:"place,omitempty" json:"place,omitempty"`
+
+	// Structured address fields. These are optional and may be used instead of, or alongside, the freeform Place.
+	City    string `yaml:"city,omitempty" json:"city,omitempty"`
+	Region  string `yaml:"region,omitempty" json:"region,omitempty"`
+	Country string `yaml:"country,omitempty" json:"country,omitempty"`
+	Postal  string `yaml:"postal,omitempty" json:"postal,omitempty"`
 
-	Meta map[string]interface{} `yaml:",inline"`
+	Meta Meta `yaml:",inline" json:"meta,omitempty"`
+}
+
+// Line returns p's address as a single line. If p has any structured address fields, they're assembled as "City, Region
+// Postal, Country", leaving out those that are empty. Otherwise, the freeform Place is returned.
+func (p Place) Line() string {
+	parts := make([]string, 0, 3)
+	if len(p.City) > 0 {
+		parts = append(parts, p.City)
+	}
+	if region := strings.TrimSpace(p.Region + " " + p.Postal); len(region) > 0 {
+		parts = append(parts, region)
+	}
+	if len(p.Country) > 0 {
+		parts = append(parts, p.Country)
+	}
+
+	if len(parts) == 0 {
+		return p.Place
+	}
+	return strings.Join(parts, ", ")
+}
+
+// normalize sets p's Place to its Line if it's empty.
+func (p *Place) normalize() {
+	if len(p.Place) == 0 {
+		p.Place = p.Line()
+	}
 }
 
 type DateRange struct {
@@ -95,6 +596,7 @@ type DateRange struct {
 	To   time.Time `yaml:"to"`
 
 	fromLayout, toLayout string
+	err                  error // The error parsing the range when it was decoded, if any.
 }
 
 func NewDateRange(from, to string) (d DateRange, err error) {
@@ -103,27 +605,147 @@ func NewDateRange(from, to string) (d DateRange, err error) {
 }
 
 type yamlDateRange struct {
-	From string `yaml:"from,omitempty"`
-	To   string `yaml:"to,omitempty"`
+	From string `yaml:"from,omitempty" json:"from,omitempty"`
+	To   string `yaml:"to,omitempty" json:"to,omitempty"`
 }
 
-func (d DateRange) MarshalYAML() (interface{}, error) {
-	var whence yamlDateRange
+// OutputLayout, if not empty, is the layout used to format both ends of every DateRange when marshalling it, instead of the
+// layouts they were parsed with. This can be used to normalize dates written back to YAML, such as to "2006-01".
+var OutputLayout string
+
+// whence returns the from and to strings of d, formatted using OutputLayout, if set, or the layouts they were parsed with.
+// Times parsed with a month name or slash layout are formatted with its canonical layout instead (see canonicalLayouts).
+// If a time has no layout, the date-only layout is used. Times are formatted in the location they were parsed in. Zero
+// times are left empty.
+func (d DateRange) whence() (whence yamlDateRange) {
+	layout := func(parsed string) string {
+		switch {
+		case len(OutputLayout) > 0:
+			return OutputLayout
+		case len(canonicalLayouts[parsed]) > 0:
+			return canonicalLayouts[parsed]
+		case len(parsed) > 0:
+			return parsed
+		default:
+			return dateLayout
+		}
+	}
 
 	if !d.From.IsZero() {
-		if len(d.fromLayout) == 0 {
-			d.fromLayout = layouts[4]
-		}
-		whence.From = d.From.Format(d.fromLayout)
+		whence.From = d.From.Format(layout(d.fromLayout))
 	}
 
 	if !d.To.IsZero() {
-		if len(d.toLayout) == 0 {
-			d.toLayout = layouts[4]
+		whence.To = d.To.Format(layout(d.toLayout))
+	}
+
+	return whence
+}
+
+// Format returns the non-zero ends of d formatted using layout and joined by " - ". If both ends are zero, the result is
+// empty. Times are formatted in the location they were parsed in.
+func (d DateRange) Format(layout string) string {
+	ends := make([]string, 0, 2)
+	for _, t := range []time.Time{d.From, d.To} {
+		if !t.IsZero() {
+			ends = append(ends, t.Format(layout))
+		}
+	}
+	return strings.Join(ends, " - ")
+}
+
+// now returns the current time. It's replaced by tests.
+var now = time.Now
+
+// Span is a length of time in whole years and months, such as the length of a DateRange.
+type Span struct {
+	Years, Months int
+}
+
+// spanOf returns the span of whole months from start to end. If end is before start, the span is zero.
+func spanOf(start, end time.Time) Span {
+	months := (end.Year()-start.Year())*12 + int(end.Month()-start.Month())
+	if end.Day() < start.Day() {
+		months--
+	}
+	if months < 0 {
+		months = 0
+	}
+	return Span{Years: months / 12, Months: months % 12}
+}
+
+// add returns the sum of s and o.
+func (s Span) add(o Span) Span {
+	months := (s.Years+o.Years)*12 + s.Months + o.Months
+	return Span{Years: months / 12, Months: months % 12}
+}
+
+// String returns s as a number of years and months, such as "2 years, 3 months" or "1 year", leaving out whichever is zero.
+// A span shorter than a month is "0 months".
+func (s Span) String() string {
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return "1 " + unit
 		}
-		whence.To = d.To.Format(d.toLayout)
+		return fmt.Sprintf("%d %ss", n, unit)
+	}
+
+	switch {
+	case s.Years > 0 && s.Months > 0:
+		return plural(s.Years, "year") + ", " + plural(s.Months, "month")
+	case s.Years > 0:
+		return plural(s.Years, "year")
+	default:
+		return plural(s.Months, "month")
+	}
+}
+
+// end returns the end of d, or the current time if d is ongoing.
+func (d DateRange) end() time.Time {
+	if d.To.IsZero() {
+		return now()
 	}
+	return d.To
+}
 
+// Duration returns the length of d in whole years and months. Ongoing ranges, without an end, are counted up to now. A
+// range without a start has no length.
+func (d DateRange) Duration() Span {
+	if d.From.IsZero() {
+		return Span{}
+	}
+	return spanOf(d.From, d.end())
+}
+
+// TotalDuration returns the combined length of ranges, in whole years and months, without counting any time more than once.
+// Overlapping ranges are merged before they're counted, so two jobs held at the same time count only for the time they
+// span together. Like Duration, ongoing ranges are counted up to now, and ranges without a start are ignored.
+func TotalDuration(ranges []DateRange) Span {
+	type interval struct{ start, end time.Time }
+	intervals := make([]interval, 0, len(ranges))
+	for _, d := range ranges {
+		if d.From.IsZero() || d.end().Before(d.From) {
+			continue
+		}
+		intervals = append(intervals, interval{d.From, d.end()})
+	}
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].start.Before(intervals[j].start) })
+
+	var total Span
+	for i := 0; i < len(intervals); {
+		cur := intervals[i]
+		for i++; i < len(intervals) && !intervals[i].start.After(cur.end); i++ {
+			if intervals[i].end.After(cur.end) {
+				cur.end = intervals[i].end
+			}
+		}
+		total = total.add(spanOf(cur.start, cur.end))
+	}
+	return total
+}
+
+func (d DateRange) MarshalYAML() (interface{}, error) {
+	whence := d.whence()
 	if len(whence.From) == 0 && len(whence.To) == 0 {
 		return nil, nil
 	}
@@ -131,6 +753,19 @@ func (d DateRange) MarshalYAML() (interface{}, error) {
 	return whence, nil
 }
 
+// MarshalJSON encodes d as an object with from and to strings, using the same layouts as MarshalYAML. An empty range is
+// encoded as null.
+func (d DateRange) MarshalJSON() ([]byte, error) {
+	whence := d.whence()
+	if len(whence.From) == 0 && len(whence.To) == 0 {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(whence)
+}
+
+// parseFromTo parses the from and to dates of a range. Either may be empty to leave that end of the range open, but any date
+// given must parse, or an error naming its field and value is returned.
 func (d *DateRange) parseFromTo(from, to string) error {
 	var fromErr, toErr error
 
@@ -152,18 +787,45 @@ func (d *DateRange) parseFromTo(from, to string) error {
 	}
 	*d = r
 
-	if fromErr != nil && toErr != nil && !(fromErr == errUndefined && toErr == errUndefined) {
-		return fmt.Errorf("Cannot parse either from: nor to: field -- errors:\nfrom: %s\nto:   %s", fromErr, toErr)
+	var msgs []string
+	if fromErr != nil && fromErr != errUndefined {
+		msgs = append(msgs, fmt.Sprintf("from: cannot parse date %q", from))
+	}
+	if toErr != nil && toErr != errUndefined {
+		msgs = append(msgs, fmt.Sprintf("to: cannot parse date %q", to))
+	}
+	if len(msgs) > 0 {
+		return errors.New(strings.Join(msgs, "; "))
 	}
 
 	return nil
 }
 
+// UnmarshalYAML decodes a date range from a mapping with from and to keys. A single date, given as a string, is decoded
+// as a range with only a start.
+//
+// A date that cannot be parsed is left zero and its error is kept (see Err). The error is returned as a *yaml.TypeError,
+// so that the rest of the document is still decoded and every such date can be found.
 func (d *DateRange) UnmarshalYAML(unmarshal func(interface{}) error) error {
-	var whence yamlDateRange
-	if err := unmarshal(&whence); err != nil {
-		return err
+	var err error
+	var date string
+	if unmarshal(&date) == nil {
+		err = d.parseFromTo(date, "")
+	} else {
+		var whence yamlDateRange
+		if err := unmarshal(&whence); err != nil {
+			return err
+		}
+		err = d.parseFromTo(whence.From, whence.To)
+	}
+
+	if d.err = err; err != nil {
+		return &yaml.TypeError{Errors: []string{err.Error()}}
 	}
+	return nil
+}
 
-	return d.parseFromTo(whence.From, whence.To)
+// Err returns the error parsing d when it was decoded, if either of its dates could not be parsed.
+func (d DateRange) Err() error {
+	return d.err
 }
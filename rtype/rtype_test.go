@@ -0,0 +1,723 @@
+package rtype
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestProfilesOrdered(t *testing.T) {
+	profiles := map[string]Profile{
+		"github":   {URL: "https://github.com/me"},
+		"twitter":  {URL: "https://twitter.com/me"},
+		"linkedin": {URL: "https://linkedin.com/in/me"},
+		"blog":     {URL: "https://example.com"},
+	}
+
+	table := []struct {
+		order []string
+		want  []string
+	}{
+		{nil, []string{"blog", "github", "linkedin", "twitter"}},
+		{[]string{"twitter", "github"}, []string{"twitter", "github", "blog", "linkedin"}},
+		{[]string{"missing", "linkedin", "linkedin"}, []string{"linkedin", "blog", "github", "twitter"}},
+	}
+
+	for _, e := range table {
+		var keys []string
+		for _, p := range (Profiles{Order: e.order, Profile: profiles}).Ordered() {
+			if p.URL != profiles[p.Key].URL {
+				t.Errorf("profile %q has URL %q; expected %q", p.Key, p.URL, profiles[p.Key].URL)
+			}
+			keys = append(keys, p.Key)
+		}
+		if !reflect.DeepEqual(keys, e.want) {
+			t.Errorf("expected order %q for %q; got %q", e.want, e.order, keys)
+		}
+	}
+
+	if got := (Profiles{}).Ordered(); len(got) != 0 {
+		t.Errorf("expected no profiles; got %v", got)
+	}
+}
+
+func TestMeName(t *testing.T) {
+	meta := Meta{
+		"given":  "Jane",
+		"family": "Doe",
+		"middle": "",
+		"Title":  "Dr.",
+	}
+
+	table := []struct {
+		order []string
+		want  string
+	}{
+		{nil, "Janie"},
+		{[]string{"Chosen"}, "Janie"},
+		{[]string{"given", "family"}, "Jane Doe"},
+		{[]string{"Title", "Given", "middle", "FAMILY"}, "Dr. Jane Doe"},
+		{[]string{"chosen", "family"}, "Janie Doe"},
+		{[]string{"missing", "middle"}, "Janie"},
+	}
+
+	for _, e := range table {
+		m := Me{Order: e.order, Chosen: "Janie", Meta: meta}
+		if got := m.Name(); got != e.want {
+			t.Errorf("expected name %q for order %q; got %q", e.want, e.order, got)
+		}
+	}
+}
+
+func TestMeOrderedFields(t *testing.T) {
+	m := Me{
+		Chosen: "Janie",
+		Email:  "jane@example.com",
+		Meta:   Meta{"given": "Jane", "middle": "", "site": "https://example.com"},
+	}
+
+	table := []struct {
+		order []string
+		want  []NameValue
+	}{
+		{nil, []NameValue{}},
+		{[]string{"missing", "middle", "phone"}, []NameValue{}},
+		{[]string{"Email", "site"}, []NameValue{{"Email", "jane@example.com"}, {"site", "https://example.com"}}},
+		{[]string{"given", "chosen", "given"}, []NameValue{{"given", "Jane"}, {"chosen", "Janie"}}},
+	}
+
+	for _, e := range table {
+		m.Order = e.order
+		if got := m.OrderedFields(); !reflect.DeepEqual(got, e.want) {
+			t.Errorf("expected fields %v for order %q; got %v", e.want, e.order, got)
+		}
+	}
+}
+
+func TestPlaceLine(t *testing.T) {
+	table := []struct {
+		place Place
+		want  string
+	}{
+		{Place{Place: "Deadtown, AL"}, "Deadtown, AL"},
+		{Place{Place: "Deadtown, AL", City: "Deadtown", Region: "AL", Postal: "35004", Country: "US"}, "Deadtown, AL 35004, US"},
+		{Place{City: "Deadtown", Country: "US"}, "Deadtown, US"},
+		{Place{Postal: "35004"}, "35004"},
+		{Place{}, ""},
+	}
+
+	for _, e := range table {
+		if got := e.place.Line(); got != e.want {
+			t.Errorf("expected line %q for %+v; got %q", e.want, e.place, got)
+		}
+	}
+}
+
+func TestDateRangeUnmarshalYAML(t *testing.T) {
+	table := []struct {
+		in       string
+		from, to string
+	}{
+		{"date: 2014-05\n", "2014-05-01", ""},
+		{"date: '2014'\n", "2014-01-01", ""},
+		{"date: {from: 2010, to: 2012-03}\n", "2010-01-01", "2012-03-01"},
+		{"date: {to: 2012}\n", "", "2012-01-01"},
+		{"date: Aug 2015\n", "2015-08-01", ""},
+		{"date: {from: January 2015, to: 08/2015}\n", "2015-01-01", "2015-08-01"},
+		{"date: 2015/08\n", "2015-08-01", ""},
+		{"date: 01/02/2015\n", "2015-01-02", ""},
+	}
+
+	format := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format("2006-01-02")
+	}
+
+	for _, e := range table {
+		var v struct{ Date DateRange }
+		if err := yaml.Unmarshal([]byte(e.in), &v); err != nil {
+			t.Errorf("unexpected error decoding %q: %v", e.in, err)
+			continue
+		}
+		if from, to := format(v.Date.From), format(v.Date.To); from != e.from || to != e.to {
+			t.Errorf("expected %q to decode to %q-%q; got %q-%q", e.in, e.from, e.to, from, to)
+		}
+	}
+
+	errTable := []struct {
+		in, want string
+	}{
+		{"date: sometime\n", `from: cannot parse date "sometime"`},
+		{"date: {from: Agust 2015, to: 2016-01}\n", `from: cannot parse date "Agust 2015"`},
+		{"date: {from: 2015-08, to: 2016-13}\n", `to: cannot parse date "2016-13"`},
+		{"date: {from: soon, to: later}\n", `from: cannot parse date "soon"; to: cannot parse date "later"`},
+	}
+
+	for _, e := range errTable {
+		var v struct {
+			Date DateRange
+			Next string
+		}
+		err := yaml.Unmarshal([]byte(e.in+"next: after\n"), &v)
+		if terr, ok := err.(*yaml.TypeError); !ok || len(terr.Errors) != 1 || terr.Errors[0] != e.want {
+			t.Errorf("expected type error %q decoding %q; got %v", e.want, e.in, err)
+		}
+		if err := v.Date.Err(); err == nil || err.Error() != e.want {
+			t.Errorf("expected Err() %q decoding %q; got %v", e.want, e.in, err)
+		}
+		if v.Next != "after" {
+			t.Errorf("expected decoding %q to continue past the bad date; got next = %q", e.in, v.Next)
+		}
+	}
+}
+
+func TestDateRangeLayouts(t *testing.T) {
+	table := []struct {
+		from, to     string
+		outputLayout string
+		want         yamlDateRange
+	}{
+		{"2010-08", "2015-12-03", "", yamlDateRange{"2010-08", "2015-12-03"}},
+		{"2010-08", "2015-12-03", "2006-01", yamlDateRange{"2010-08", "2015-12"}},
+		{"2010-01-02 15:04 -0800", "2011-06-02T09:30:00+09:00", "", yamlDateRange{"2010-01-02 15:04 -0800", "2011-06-02T09:30:00+09:00"}},
+		{"2010-01-02 15:04 -0800", "", "2006-01-02 15:04 -0700", yamlDateRange{"2010-01-02 15:04 -0800", ""}},
+		{"2010-01-02 15:04:05 UTC", "", "", yamlDateRange{"2010-01-02 15:04:05 UTC", ""}},
+		{"Aug 2015", "August 2016", "", yamlDateRange{"2015-08", "2016-08"}},
+		{"08/2015", "2016/8", "", yamlDateRange{"2015-08", "2016-08"}},
+		{"8/2015", "2016/08", "", yamlDateRange{"2015-08", "2016-08"}},
+		{"01/02/2015", "12/31/2015", "", yamlDateRange{"2015-01-02", "2015-12-31"}},
+		{"Aug 2015", "", "Jan 2006", yamlDateRange{"Aug 2015", ""}},
+	}
+
+	defer func(l string) { OutputLayout = l }(OutputLayout)
+	for _, e := range table {
+		d, err := NewDateRange(e.from, e.to)
+		if err != nil {
+			t.Errorf("cannot parse date range %q-%q: %v", e.from, e.to, err)
+			continue
+		}
+
+		OutputLayout = e.outputLayout
+		if got := d.whence(); got != e.want {
+			t.Errorf("expected %q-%q with output layout %q to be written as %q; got %q", e.from, e.to, e.outputLayout, e.want, got)
+		}
+	}
+}
+
+func TestDateRangeFormat(t *testing.T) {
+	table := []struct {
+		from, to string
+		want     string
+	}{
+		{"2010-08", "2015-12", "Aug 2010 - Dec 2015"},
+		{"2010-08", "", "Aug 2010"},
+		{"", "2015-12", "Dec 2015"},
+		{"", "", ""},
+	}
+
+	for _, e := range table {
+		d, err := NewDateRange(e.from, e.to)
+		if err != nil {
+			t.Errorf("cannot parse date range %q-%q: %v", e.from, e.to, err)
+			continue
+		}
+		if got := d.Format("Jan 2006"); got != e.want {
+			t.Errorf("expected %q-%q to format as %q; got %q", e.from, e.to, e.want, got)
+		}
+	}
+
+	// Times keep the offset they were parsed with instead of being converted to UTC or the local time zone.
+	d, err := NewDateRange("2010-01-02 23:30 -0800", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := d.Format("2006-01-02 15:04"), "2010-01-02 23:30"; got != want {
+		t.Errorf("expected %q; got %q", want, got)
+	}
+}
+
+func TestMarshalYAMLMetaOrder(t *testing.T) {
+	keys := []string{"zulu", "alpha", "mike", "echo", "Bravo", "delta", "yankee", "charlie", "xray", "kilo"}
+	meta := Meta{}
+	nested := map[interface{}]interface{}{}
+	for i, k := range keys {
+		meta[k] = i
+		nested[k] = i
+	}
+	meta["nested"] = nested
+
+	r := Resume{
+		Me:         Me{Chosen: "Jane", Meta: meta},
+		Profiles:   Profiles{Profile: map[string]Profile{"b": {URL: "b", Meta: meta}, "a": {URL: "a"}}},
+		Employment: []Employment{{Title: "T", Meta: meta}},
+		Meta:       meta,
+	}
+
+	first, err := yaml.Marshal(r)
+	if err != nil {
+		t.Fatalf("unexpected error marshalling resume: %v", err)
+	}
+
+	for i := 0; i < 20; i++ {
+		b, err := yaml.Marshal(r)
+		if err != nil {
+			t.Fatalf("unexpected error marshalling resume: %v", err)
+		}
+		if string(b) != string(first) {
+			t.Fatalf("marshalling the same resume gave different YAML:\n%s\n---\n%s", first, b)
+		}
+	}
+
+	// Inline metadata follows the struct's own fields, in sorted order.
+	var doc struct {
+		Me yaml.MapSlice `yaml:"me"`
+	}
+	if err := yaml.Unmarshal(first, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, item := range doc.Me {
+		got = append(got, item.Key.(string))
+	}
+	want := []string{"ordered", "chosen", "phone", "email",
+		"Bravo", "alpha", "charlie", "delta", "echo", "kilo", "mike", "nested", "xray", "yankee", "zulu"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected me keys in order %q; got %q", want, got)
+	}
+}
+
+func TestDateRangeMarshalJSON(t *testing.T) {
+	table := []struct {
+		from, to string
+		want     string
+	}{
+		{"2010-08", "2015-12", `{"from":"2010-08","to":"2015-12"}`},
+		// Open-ended ranges, as in work that's ongoing, have no to date.
+		{"2016-03", "", `{"from":"2016-03"}`},
+		{"", "2015-12-31", `{"to":"2015-12-31"}`},
+		{"", "", `null`},
+	}
+
+	for _, e := range table {
+		d, err := NewDateRange(e.from, e.to)
+		if err != nil {
+			t.Fatalf("unexpected error parsing %q to %q: %v", e.from, e.to, err)
+		}
+		b, err := json.Marshal(d)
+		if err != nil {
+			t.Errorf("unexpected error marshalling %q to %q: %v", e.from, e.to, err)
+		} else if string(b) != e.want {
+			t.Errorf("json.Marshal(%q to %q) = %s; want %s", e.from, e.to, b, e.want)
+		}
+	}
+}
+
+func TestMetaMarshalJSON(t *testing.T) {
+	var r Resume
+	src := `
+me:
+  chosen: Jane
+manager:
+  name: Damien
+  1: one
+  reports:
+  - name: Ann
+    level: 2
+hidden: true
+`
+	if err := yaml.Unmarshal([]byte(src), &r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := r.Meta["manager"].(map[interface{}]interface{}); !ok {
+		t.Fatalf("expected YAML to decode manager as map[interface{}]interface{}; got %T", r.Meta["manager"])
+	}
+
+	b, err := json.Marshal(r.Meta)
+	if err != nil {
+		t.Fatalf("unexpected error marshalling metadata: %v", err)
+	}
+	want := `{"hidden":true,"manager":{"1":"one","name":"Damien","reports":[{"level":2,"name":"Ann"}]}}`
+	if string(b) != want {
+		t.Errorf("json.Marshal(meta) = %s; want %s", b, want)
+	}
+
+	// Marshalling doesn't convert the metadata itself.
+	if _, ok := r.Meta["manager"].(map[interface{}]interface{}); !ok {
+		t.Errorf("marshalling changed manager to %T", r.Meta["manager"])
+	}
+
+	if b, err := json.Marshal(Me{Chosen: "Jane"}); err != nil || strings.Contains(string(b), `"meta"`) {
+		t.Errorf("json.Marshal(Me) = %s, %v; want no meta key for nil metadata", b, err)
+	}
+}
+
+func TestNormalizeURL(t *testing.T) {
+	table := []struct {
+		in, want string
+	}{
+		{"github.com/me", "https://github.com/me"},
+		{"www.linkedin.com/in/me/", "https://www.linkedin.com/in/me/"},
+		{"example.com", "https://example.com"},
+		{"//example.com/me", "https://example.com/me"},
+		{"https://github.com/me", "https://github.com/me"},
+		{"http://example.com", "http://example.com"},
+		{"mailto:me@example.com", "mailto:me@example.com"},
+		{"/cv.pdf", "/cv.pdf"},
+		{"me/profile", "me/profile"},
+		{"http://[::1", "http://[::1"},
+		{"", ""},
+	}
+
+	for _, e := range table {
+		if got := NormalizeURL(e.in); got != e.want {
+			t.Errorf("NormalizeURL(%q) = %q; want %q", e.in, got, e.want)
+		}
+	}
+
+	var r Resume
+	src := "profiles:\n  github:\n    url: github.com/me\n    label: me\n    since: 2010\n"
+	if err := yaml.Unmarshal([]byte(src), &r); err != nil {
+		t.Fatalf("cannot unmarshal profiles: %v", err)
+	}
+	p := r.Profiles.Profile["github"]
+	if p.URL != "https://github.com/me" || p.Label != "me" || p.Meta["since"] != 2010 {
+		t.Errorf("unmarshaled profile = %+v; want normalized URL, label, and metadata", p)
+	}
+}
+
+func TestNormalizeMeOrder(t *testing.T) {
+	var r Resume
+	r.Me.Chosen = "Jane"
+	r.Normalize()
+	if !reflect.DeepEqual(r.Me.Order, []string{"chosen"}) || r.Me.Name() != "Jane" {
+		t.Errorf("Me.Order = %q, Me.Name() = %q; want [chosen] and %q", r.Me.Order, r.Me.Name(), "Jane")
+	}
+
+	r.Me.Order = []string{"given", "family"}
+	r.Normalize()
+	if !reflect.DeepEqual(r.Me.Order, []string{"given", "family"}) {
+		t.Errorf("Me.Order = %q; want it unchanged", r.Me.Order)
+	}
+}
+
+func TestNormalizeSummary(t *testing.T) {
+	table := []struct {
+		summary string
+		meta    Meta
+		want    string
+	}{
+		{"", Meta{"statement": "Builds servers."}, "Builds servers."},
+		{"Writes postmortems.", Meta{"statement": "Builds servers."}, "Writes postmortems."},
+		{"", Meta{"statement": 5}, ""},
+		{"", nil, ""},
+	}
+
+	for _, e := range table {
+		r := Resume{Summary: e.summary, Meta: e.meta}
+		for i := 0; i < 2; i++ {
+			r.Normalize()
+			if r.Summary != e.want {
+				t.Errorf("Normalize() with summary %q and meta %v: Summary = %q; want %q", e.summary, e.meta, r.Summary, e.want)
+			}
+		}
+		if _, ok := r.Meta["statement"]; !ok && e.meta != nil {
+			t.Errorf("Normalize() removed the statement metadata key")
+		}
+	}
+}
+
+func TestMergeSummary(t *testing.T) {
+	var r Resume
+	if err := yaml.Unmarshal([]byte("summary: Builds servers.\nstatement: Old.\n"), &r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r.Merge(Resume{Summary: "Included."})
+	if r.Summary != "Builds servers." || r.Meta["statement"] != "Old." {
+		t.Errorf("Summary = %q, Meta = %v; want the summary kept and the statement in Meta", r.Summary, r.Meta)
+	}
+
+	r = Resume{}
+	r.Merge(Resume{Summary: "Included."})
+	if r.Summary != "Included." {
+		t.Errorf("Summary = %q; want the included summary used when there's none", r.Summary)
+	}
+}
+
+func TestNormalizeProfileLabels(t *testing.T) {
+	r := Resume{Profiles: Profiles{Profile: map[string]Profile{
+		"github":  {URL: "https://github.com/me"},
+		"port":    {URL: "https://example.com:8443/me"},
+		"labeled": {URL: "https://example.org", Label: "Mine"},
+		"nohost":  {URL: "mailto:me@example.com"},
+		"empty":   {},
+	}}}
+
+	want := map[string]string{
+		"github":  "github.com",
+		"port":    "example.com",
+		"labeled": "Mine",
+		"nohost":  "",
+		"empty":   "",
+	}
+	for i := 0; i < 2; i++ {
+		r.Normalize()
+		for k, label := range want {
+			if got := r.Profiles.Profile[k].Label; got != label {
+				t.Errorf("profile %s label = %q; want %q", k, got, label)
+			}
+		}
+	}
+}
+
+func TestNormalizePlaces(t *testing.T) {
+	r := Resume{
+		Employment: []Employment{
+			{Where: Place{City: "Deadtown", Region: "AL", Country: "US"}},
+			{Where: Place{City: "Deadtown", Place: "Remote"}},
+			{Where: Place{Name: "Nowhere"}},
+		},
+		Education: []Education{
+			{Where: Place{City: "Springfield", Postal: "12345"}},
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		r.Normalize()
+		for j, want := range []string{"Deadtown, AL, US", "Remote", ""} {
+			if got := r.Employment[j].Where.Place; got != want {
+				t.Errorf("work[%d].where.place = %q; want %q", j, got, want)
+			}
+		}
+		if got, want := r.Education[0].Where.Place, "Springfield, 12345"; got != want {
+			t.Errorf("education[0].where.place = %q; want %q", got, want)
+		}
+	}
+}
+
+func TestOmit(t *testing.T) {
+	r := Resume{
+		Me:         Me{Chosen: "Jane"},
+		Awards:     []Award{{Title: "Award"}},
+		References: []Reference{{Name: "Ref"}},
+	}
+
+	if err := r.Omit("references"); err != nil || r.References != nil {
+		t.Errorf("Omit(references) = %v; references = %+v", err, r.References)
+	}
+	if r.Me.Chosen != "Jane" || len(r.Awards) != 1 {
+		t.Errorf("Omit(references) changed other sections: %+v", r)
+	}
+
+	for _, section := range Sections {
+		if err := r.Omit(section); err != nil {
+			t.Errorf("Omit(%q) = %v; want no error", section, err)
+		}
+	}
+	if !reflect.DeepEqual(r, Resume{}) {
+		t.Errorf("expected an empty resume after omitting all sections; got %+v", r)
+	}
+
+	if err := r.Omit("hobbies"); err == nil {
+		t.Error("expected an error omitting an unknown section")
+	}
+}
+
+func TestDateRangeMarshalYAMLCanonical(t *testing.T) {
+	var e Award
+	if err := yaml.Unmarshal([]byte("title: T\ndate: {from: Aug 2015, to: 08/31/2016}\n"), &e); err != nil {
+		t.Fatalf("cannot parse award: %v", err)
+	}
+
+	b, err := yaml.Marshal(e)
+	if err != nil {
+		t.Fatalf("cannot marshal award: %v", err)
+	}
+	if want := "title: T\ndate:\n  from: 2015-08\n  to: \"2016-08-31\"\n"; string(b) != want {
+		t.Errorf("expected %q; got %q", want, b)
+	}
+
+	var again Award
+	if err = yaml.Unmarshal(b, &again); err != nil {
+		t.Fatalf("cannot parse marshalled award: %v", err)
+	}
+	if !again.Date.From.Equal(e.Date.From) || !again.Date.To.Equal(e.Date.To) {
+		t.Errorf("expected %v to round-trip; got %v", e.Date, again.Date)
+	}
+}
+
+func TestDateRangeDuration(t *testing.T) {
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return time.Date(2020, time.March, 15, 0, 0, 0, 0, time.UTC) }
+
+	table := []struct {
+		from, to string
+		want     Span
+		str      string
+	}{
+		{"2010-01", "2012-04", Span{2, 3}, "2 years, 3 months"},
+		{"2010-01", "2011-01", Span{1, 0}, "1 year"},
+		{"2010-01-31", "2010-03-01", Span{0, 1}, "1 month"},
+		{"2010-01-15", "2010-02-14", Span{}, "0 months"},
+		{"2019-01", "", Span{1, 2}, "1 year, 2 months"},
+		{"", "2012-01", Span{}, "0 months"},
+		{"2012-01", "2010-01", Span{}, "0 months"},
+	}
+
+	for _, e := range table {
+		d, err := NewDateRange(e.from, e.to)
+		if err != nil {
+			t.Fatalf("cannot parse date range %q-%q: %v", e.from, e.to, err)
+		}
+		if got := d.Duration(); got != e.want || got.String() != e.str {
+			t.Errorf("Duration of %q-%q = %v (%q); want %v (%q)", e.from, e.to, got, got.String(), e.want, e.str)
+		}
+	}
+}
+
+func TestTotalDuration(t *testing.T) {
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return time.Date(2020, time.March, 1, 0, 0, 0, 0, time.UTC) }
+
+	table := []struct {
+		ranges [][2]string
+		want   Span
+	}{
+		{nil, Span{}},
+		// Back to back.
+		{[][2]string{{"2010-01", "2012-01"}, {"2012-01", "2013-06"}}, Span{3, 5}},
+		// Overlapping, given in any order.
+		{[][2]string{{"2011-01", "2013-01"}, {"2010-01", "2012-01"}}, Span{3, 0}},
+		// Contained within another.
+		{[][2]string{{"2010-01", "2015-01"}, {"2011-01", "2012-01"}}, Span{5, 0}},
+		// A gap between jobs isn't counted.
+		{[][2]string{{"2010-01", "2011-01"}, {"2012-01", "2012-07"}}, Span{1, 6}},
+		// Ongoing, overlapping a part-time job, and without a start.
+		{[][2]string{{"2018-01", ""}, {"2019-01", "2019-06"}, {"", "2005-01"}}, Span{2, 2}},
+	}
+
+	for _, e := range table {
+		var ranges []DateRange
+		for _, r := range e.ranges {
+			d, err := NewDateRange(r[0], r[1])
+			if err != nil {
+				t.Fatalf("cannot parse date range %q: %v", r, err)
+			}
+			ranges = append(ranges, d)
+		}
+		if got := TotalDuration(ranges); got != e.want {
+			t.Errorf("TotalDuration(%q) = %v; want %v", e.ranges, got, e.want)
+		}
+	}
+}
+
+func TestHighlights(t *testing.T) {
+	in := `
+work:
+- title: T
+  desc: Description only.
+- title: U
+  highlights:
+  - First
+  - Second ((https://example.com link))
+education:
+- highlights: [Dean's list]
+`
+	var r Resume
+	if err := yaml.Unmarshal([]byte(in), &r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if e := r.Employment[0]; e.Description != "Description only." || e.Highlights != nil {
+		t.Errorf("expected a description and no highlights; got %q, %q", e.Description, e.Highlights)
+	}
+	if e, want := r.Employment[1], []string{"First", "Second ((https://example.com link))"}; !reflect.DeepEqual(e.Highlights, want) {
+		t.Errorf("Highlights = %q; want %q", e.Highlights, want)
+	} else if _, ok := e.Meta["highlights"]; ok {
+		t.Errorf("highlights should not be metadata: %v", e.Meta)
+	}
+	if want := []string{"Dean's list"}; !reflect.DeepEqual(r.Education[0].Highlights, want) {
+		t.Errorf("education Highlights = %q; want %q", r.Education[0].Highlights, want)
+	}
+
+	b, err := yaml.Marshal(r.Employment[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if strings.Contains(string(b), "highlights") {
+		t.Errorf("empty highlights should be omitted:\n%s", b)
+	}
+}
+
+func TestTagFilterKeep(t *testing.T) {
+	table := []struct {
+		filter TagFilter
+		tags   []string
+		want   bool
+	}{
+		{TagFilter{}, nil, true},
+		{TagFilter{}, []string{"go"}, true},
+		{TagFilter{Include: []string{"go"}}, []string{"GO", "backend"}, true},
+		{TagFilter{Include: []string{"go"}}, []string{"frontend"}, false},
+		{TagFilter{Include: []string{"go"}}, nil, true},
+		{TagFilter{Include: []string{"go"}, TaggedOnly: true}, nil, false},
+		{TagFilter{TaggedOnly: true}, []string{"frontend"}, true},
+		{TagFilter{Exclude: []string{"leadership"}}, []string{"leadership"}, false},
+		{TagFilter{Include: []string{"go"}, Exclude: []string{"leadership"}}, []string{"go", "leadership"}, false},
+		{TagFilter{Exclude: []string{"leadership"}}, nil, true},
+	}
+
+	for _, e := range table {
+		if got := e.filter.Keep(e.tags); got != e.want {
+			t.Errorf("%+v.Keep(%q) = %t; want %t", e.filter, e.tags, got, e.want)
+		}
+	}
+}
+
+func TestFilterTags(t *testing.T) {
+	r := Resume{
+		Employment:   []Employment{{Title: "A", Tags: []string{"go"}}, {Title: "B", Tags: []string{"js"}}, {Title: "C"}},
+		Education:    []Education{{Received: "D", Tags: []string{"js"}}},
+		Awards:       []Award{{Title: "E"}},
+		Publications: []Publication{{Title: "F", Tags: []string{"go"}}},
+		References:   []Reference{{Name: "G", Tags: []string{"js"}}},
+	}
+	r.FilterTags(TagFilter{Include: []string{"go"}})
+
+	want := Resume{
+		Employment:   []Employment{{Title: "A", Tags: []string{"go"}}, {Title: "C"}},
+		Education:    []Education{},
+		Awards:       []Award{{Title: "E"}},
+		Publications: []Publication{{Title: "F", Tags: []string{"go"}}},
+		References:   []Reference{},
+	}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("FilterTags:\ngot  %+v\nwant %+v", r, want)
+	}
+}
+
+func TestPhotoIsURL(t *testing.T) {
+	table := []struct {
+		photo string
+		want  bool
+	}{
+		{"https://example.com/me.jpg", true},
+		{"http://example.com/me.jpg", true},
+		{"me.jpg", false},
+		{"/images/me.jpg", false},
+		{"javascript:alert(1)", false},
+		{"https:me.jpg", false},
+		{"", false},
+	}
+
+	for _, e := range table {
+		if got := (Me{Photo: e.photo}).PhotoIsURL(); got != e.want {
+			t.Errorf("PhotoIsURL(%q) = %t; want %t", e.photo, got, e.want)
+		}
+	}
+}
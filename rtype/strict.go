@@ -0,0 +1,107 @@
+package rtype
+
+import (
+	yaml "gopkg.in/yaml.v2"
+)
+
+// The strict* types mirror the resume types without their inline Meta fields, so that decoding into them with
+// yaml.UnmarshalStrict reports keys that would otherwise be swallowed as metadata. They must be kept in sync with the types
+// they mirror.
+
+type strictResume struct {
+	Me           strictMe            `yaml:"me"`
+	Profiles     strictProfiles      `yaml:"profiles"`
+	Summary      string              `yaml:"summary,omitempty"`
+	Employment   []strictEmployment  `yaml:"work,omitempty"`
+	Education    []strictEducation   `yaml:"education,omitempty"`
+	Awards       []strictAward       `yaml:"awards,omitempty"`
+	Publications []strictPublication `yaml:"publications,omitempty"`
+	References   []strictReference   `yaml:"references,omitempty"`
+	Include      []string            `yaml:"include,omitempty"`
+}
+
+type strictMe struct {
+	Order  []string `yaml:"ordered,flow"`
+	Chosen string   `yaml:"chosen"`
+	Phone  string   `yaml:"phone"`
+	Email  string   `yaml:"email"`
+	Photo  string   `yaml:"photo,omitempty"`
+}
+
+type strictProfiles struct {
+	Order   []string                 `yaml:".order,flow"`
+	Profile map[string]strictProfile `yaml:",inline"`
+}
+
+type strictProfile struct {
+	URL   string `yaml:"url,omitempty"`
+	Label string `yaml:"label,omitempty"`
+}
+
+type strictEmployment struct {
+	Title       string      `yaml:"title"`
+	When        DateRange   `yaml:"when"`
+	Where       strictPlace `yaml:"where"`
+	Description string      `yaml:"desc,omitempty"`
+	Highlights  []string    `yaml:"highlights,omitempty"`
+	Tags        []string    `yaml:"tags,omitempty"`
+}
+
+type strictEducation struct {
+	Where       strictPlace `yaml:"where"`
+	When        DateRange   `yaml:"when"`
+	Received    string      `yaml:"received,omitempty"`
+	Fields      []string    `yaml:"fields,omitempty"`
+	Description string      `yaml:"desc,omitempty"`
+	Highlights  []string    `yaml:"highlights,omitempty"`
+	Tags        []string    `yaml:"tags,omitempty"`
+}
+
+type strictAward struct {
+	Title   string    `yaml:"title"`
+	Awarder string    `yaml:"awarder,omitempty"`
+	Date    DateRange `yaml:"date"`
+	Summary string    `yaml:"summary,omitempty"`
+	Tags    []string  `yaml:"tags,omitempty"`
+}
+
+type strictPublication struct {
+	Title     string    `yaml:"title"`
+	Publisher string    `yaml:"publisher,omitempty"`
+	Date      DateRange `yaml:"date"`
+	URL       string    `yaml:"url,omitempty"`
+	Summary   string    `yaml:"summary,omitempty"`
+	Tags      []string  `yaml:"tags,omitempty"`
+}
+
+type strictReference struct {
+	Name         string   `yaml:"name"`
+	Relationship string   `yaml:"relationship,omitempty"`
+	Contact      string   `yaml:"contact,omitempty"`
+	Note         string   `yaml:"note,omitempty"`
+	Tags         []string `yaml:"tags,omitempty"`
+}
+
+type strictPlace struct {
+	Name    string `yaml:"name,omitempty"`
+	Place   string `yaml:"place,omitempty"`
+	City    string `yaml:"city,omitempty"`
+	Region  string `yaml:"region,omitempty"`
+	Country string `yaml:"country,omitempty"`
+	Postal  string `yaml:"postal,omitempty"`
+}
+
+// UnmarshalStrict decodes the YAML document in b into r. Unlike yaml.Unmarshal, any key that isn't a field of the type it's
+// found in is an error, including keys that would otherwise be kept as metadata. The returned error lists every unknown key
+// found. As with yaml.Unmarshal, r is still decoded if the error is a *yaml.TypeError.
+func UnmarshalStrict(b []byte, r *Resume) error {
+	var strict strictResume
+	err := yaml.UnmarshalStrict(b, &strict)
+	if _, ok := err.(*yaml.TypeError); err != nil && !ok {
+		return err
+	}
+	if rerr := yaml.Unmarshal(b, r); err == nil {
+		err = rerr
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+package rtype
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// yamlKeys returns the sorted YAML keys of the non-Meta fields of the struct type t.
+func yamlKeys(t reflect.Type) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Name == "Meta" {
+			continue
+		}
+		keys = append(keys, f.Name+":"+strings.Split(f.Tag.Get("yaml"), ",")[0])
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestStrictTypesMatch(t *testing.T) {
+	table := []struct {
+		typ, strict interface{}
+	}{
+		{Resume{}, strictResume{}},
+		{Me{}, strictMe{}},
+		{Profiles{}, strictProfiles{}},
+		{Profile{}, strictProfile{}},
+		{Employment{}, strictEmployment{}},
+		{Education{}, strictEducation{}},
+		{Award{}, strictAward{}},
+		{Publication{}, strictPublication{}},
+		{Reference{}, strictReference{}},
+		{Place{}, strictPlace{}},
+	}
+
+	for _, e := range table {
+		typ, strict := reflect.TypeOf(e.typ), reflect.TypeOf(e.strict)
+		if want, got := yamlKeys(typ), yamlKeys(strict); !reflect.DeepEqual(want, got) {
+			t.Errorf("%v fields do not match %v:\nwant %q\ngot  %q", strict, typ, want, got)
+		}
+	}
+}
+
+func TestUnmarshalStrict(t *testing.T) {
+	table := []struct {
+		in      string
+		unknown []string
+	}{
+		{"me: {chosen: Name}\nwork:\n- title: T\n  when: {from: 2010}\n", nil},
+		{"work:\n- title: T\n  highlights: [A, B]\n  tags: [go]\neducation:\n- highlights: [C]\n", nil},
+		{"employmnet: []\n", []string{"employmnet"}},
+		{"awards:\n- title: T\n  date: 2014-05\n  by: B\n", []string{"by"}},
+		{"me: {chosen: Name, nickname: N}\n", []string{"nickname"}},
+		{"profiles:\n  github: {url: u, user: me}\n", []string{"user"}},
+		{"work:\n- title: T\n  manager: M\n  when: {from: 2010, until: 2011}\n", []string{"manager", "until"}},
+	}
+
+	for _, e := range table {
+		var r Resume
+		err := UnmarshalStrict([]byte(e.in), &r)
+		if (err == nil) != (len(e.unknown) == 0) {
+			t.Errorf("unexpected error result for %q: %v", e.in, err)
+			continue
+		}
+		for _, k := range e.unknown {
+			if !strings.Contains(err.Error(), "field "+k+" not found") {
+				t.Errorf("expected unknown field %q to be reported for %q; got %v", k, e.in, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	textt "text/template"
+
+	"github.com/nilium/resify/render"
+)
+
+// renderRun renders resume files for the render command, as set up by its flags. renderAll may be called more than once,
+// such as by -watch, and loads templates again each time.
+type renderRun struct {
+	args     []string // The resume files to render.
+	readOpts readOptions
+
+	useText      bool
+	ext          string // The template extension.
+	template     string // The template to execute.
+	useJSON      bool   // Whether resumes are written as JSON instead of rendered with a template.
+	useCSV       bool   // Whether a section of each resume is exported as a table instead of rendered with a template.
+	exportFormat string
+	section      string // The section exported by useCSV.
+	indentJSON   bool
+
+	outputPath    string // The output path, or the pattern each input's output path is expanded from.
+	defaultOutput string // The output path given by -o, or its default.
+	outputGiven   bool   // Whether -o was given, so the main template's front matter can't change the output path.
+	newline       bool
+	bundle        bool
+	stream        bool
+	dryRun        bool
+	check         bool
+	keepGoing     bool
+	jobs          int
+
+	pattern  *textt.Template  // The output path pattern, or nil if outputPath isn't one.
+	renderer *render.Renderer // The templates loaded by renderAll, or nil if useTemplate is false.
+
+	// output is where every input is written, unless the output path is a pattern or nothing is written.
+	output *outputWriter
+
+	// dryRunSize is the size of the output that would have been written by write if not for -dry-run.
+	dryRunSize int
+
+	// checked is the output that would have been written by write if not for -check, unless the output path is a pattern.
+	checked bytes.Buffer
+}
+
+// useTemplate returns whether resumes are rendered with a template, rather than written as JSON or a table.
+func (r *renderRun) useTemplate() bool {
+	return !r.useJSON && !r.useCSV
+}
+
+// renderTo renders the resume at path to w. It may be called concurrently once templates are loaded. Errors are logged
+// before being returned, with their exit codes. If w is an *outputWriter, errors writing to it are returned as such
+// instead of as template errors.
+func (r *renderRun) renderTo(path string, w io.Writer) error {
+	resume, err := readResumeFromFile(path, r.readOpts)
+	if err != nil {
+		return withExitCode(exitParse, err)
+	}
+
+	if r.useCSV {
+		b, err := marshalCSV(resume, r.section, r.exportFormat == exportTSV)
+		if err != nil {
+			log.Println("cannot encode", path, "as", strings.ToUpper(r.exportFormat)+":", err)
+			return withExitCode(exitParse, err)
+		}
+		// Fields may begin or end with whitespace, so only the table's last line ending is removed, leaving -newline to
+		// add it back.
+		_, err = w.Write(bytes.TrimSuffix(b, []byte("\n")))
+		return withExitCode(exitIO, err)
+	} else if r.useJSON {
+		marshal := marshalJSON
+		if r.exportFormat == exportJSONResume {
+			marshal = marshalJSONResume
+		}
+		b, err := marshal(resume, r.indentJSON)
+		if err != nil {
+			log.Println("cannot encode", path, "as JSON:", err)
+			return withExitCode(exitParse, err)
+		}
+		_, err = w.Write(b)
+		return withExitCode(exitIO, err)
+	}
+
+	// Templates write their output in many small pieces, which are batched for outputs that aren't in memory.
+	bw := bufio.NewWriter(w)
+	err = r.renderer.Execute(bw, render.Data{Resume: resume, SourceModTime: sourceModTime(path)})
+	if ferr := bw.Flush(); err == nil {
+		err = ferr
+	}
+	if err != nil {
+		if o, ok := w.(*outputWriter); ok && o.err != nil {
+			return withExitCode(exitIO, o.err)
+		}
+		log.Println("cannot execute template:", err)
+		return withExitCode(exitTemplate, err)
+	}
+	return nil
+}
+
+// renderFile renders the resume at path and returns the result, bundled and trimmed. It may be called concurrently once
+// templates are loaded. Errors are logged before being returned, with their exit codes.
+func (r *renderRun) renderFile(path string) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := r.renderTo(path, &buf); err != nil {
+		return nil, err
+	}
+
+	b := buf.Bytes()
+	if r.useCSV {
+		return b, nil
+	}
+	if r.bundle {
+		var err error
+		if b, err = r.renderer.Bundle(b); err != nil {
+			log.Printf("cannot bundle %s: %v", path, err)
+			return nil, withExitCode(exitTemplate, err)
+		}
+	}
+	return trimOutput(b), nil
+}
+
+// write writes b, rendered from the resume at path, to its output. Errors are logged before being returned, with their
+// exit codes.
+func (r *renderRun) write(path string, b []byte) error {
+	size := len(b)
+	if r.newline {
+		size++
+	}
+
+	if r.pattern != nil {
+		out, err := expandOutputPattern(r.pattern, path)
+		if err != nil {
+			log.Printf("cannot get output path for %s: %v", path, err)
+			return withExitCode(exitTemplate, err)
+		}
+
+		if r.dryRun {
+			log.Printf("would %s %s (%d bytes) from %s", outputAction(out), out, size, path)
+			return nil
+		} else if r.check {
+			if r.newline {
+				b = append(b[:len(b):len(b)], '\n')
+			}
+			return checkOutput(out, b)
+		}
+
+		if err = writeOutputFile(out, b, r.newline); err != nil {
+			log.Printf("cannot write %s: %v", out, err)
+		}
+		return withExitCode(exitIO, err)
+	}
+
+	if r.dryRun {
+		r.dryRunSize += len(b)
+		return nil
+	} else if r.check {
+		r.checked.Write(b)
+		return nil
+	}
+
+	_, err := r.output.Write(b)
+	return withExitCode(exitIO, err)
+}
+
+// streamTo renders the resume at path straight to its output, so the rendered output is never held in memory as a whole.
+// Errors are logged before being returned, with their exit codes.
+func (r *renderRun) streamTo(path string) error {
+	if r.pattern == nil {
+		return r.renderTo(path, r.output)
+	}
+
+	out, err := expandOutputPattern(r.pattern, path)
+	if err != nil {
+		log.Printf("cannot get output path for %s: %v", path, err)
+		return withExitCode(exitTemplate, err)
+	}
+	if err = os.MkdirAll(filepath.Dir(out), 0777); err != nil {
+		log.Printf("cannot write %s: %v", out, err)
+		return withExitCode(exitIO, err)
+	}
+	o, err := createOutput(out)
+	if err != nil {
+		return withExitCode(exitIO, err)
+	}
+	err = r.renderTo(path, o)
+	if cerr := o.Close(r.newline && err == nil); err == nil {
+		err = withExitCode(exitIO, cerr)
+	}
+	return err
+}
+
+// renderAll loads templates and renders every input, returning exitOK if all succeeded or the exit code of the first
+// failure otherwise. Unless the output path is a pattern, the output file is created (or truncated) each time. Files
+// embedded by templates are read again each time.
+func (r *renderRun) renderAll() (rc int) {
+	if r.useTemplate() {
+		renderer, err := newRenderer(r.useText, r.ext, r.template)
+		if err != nil {
+			return exitTemplate
+		}
+		r.renderer = renderer
+
+		// Unless -o is given, the output path may be given by the main template's front matter instead.
+		if !r.outputGiven {
+			r.outputPath = r.defaultOutput
+			if out := renderer.FrontMatter().Output; out != "" {
+				debugf("writing to %s, as given by the front matter of %s", out, renderer.Name())
+				r.outputPath = out
+			}
+			if r.pattern, err = parseOutputPattern(r.outputPath); err != nil {
+				log.Printf("cannot parse output path %q: %v", r.outputPath, err)
+				return exitTemplate
+			}
+		}
+	}
+
+	if r.check && r.pattern == nil && (r.outputPath == "" || r.outputPath == "-") {
+		log.Println("-check needs an output file to compare with, such as one given by -o")
+		return exitUsage
+	}
+	if r.pattern != nil {
+		if err := checkOutputPaths(r.pattern, r.args); err != nil {
+			log.Printf("cannot use output path %q: %v; use {{.Dir}} to tell inputs with the same name apart", r.outputPath, err)
+			return exitUsage
+		}
+	}
+
+	r.dryRunSize = 0
+	r.checked.Reset()
+	if r.pattern == nil && !r.dryRun && !r.check {
+		out, err := createOutput(r.outputPath)
+		if err != nil {
+			return exitIO
+		}
+		r.output = out
+
+		// Only end the output with a newline if every input was written, and fail if the output can't be closed.
+		defer func() {
+			if err := out.Close(r.newline && rc == exitOK); err != nil && rc == exitOK {
+				rc = exitIO
+			}
+		}()
+	}
+
+	// next renders and writes the input at index i.
+	var next func(i int) error
+	if r.stream && !r.dryRun && !r.check {
+		// Streamed inputs are rendered one at a time, since each is written as it's rendered.
+		next = func(i int) error { return r.streamTo(r.args[i]) }
+	} else {
+		// Inputs are rendered concurrently, but written in order.
+		done := make(chan struct{})
+		defer close(done)
+		results := renderConcurrently(len(r.args), r.jobs, func(i int) ([]byte, error) { return r.renderFile(r.args[i]) }, done)
+		next = func(i int) error {
+			res := <-results[i]
+			if res.err != nil {
+				return res.err
+			}
+			return r.write(r.args[i], res.b)
+		}
+	}
+
+	// Stale outputs aren't failures to render, so every one is reported, with or without -keep-going.
+	var failed []string
+	stale := 0
+	for i, arg := range r.args {
+		err := next(i)
+		if errors.Is(err, errStale) {
+			stale++
+		} else if err != nil {
+			if !r.keepGoing {
+				return exitCode(err)
+			}
+			if len(failed) == 0 {
+				rc = exitCode(err)
+			}
+			failed = append(failed, arg)
+		}
+	}
+
+	if stale > 0 {
+		log.Printf("%d of %d outputs are stale", stale, len(r.args))
+	}
+	if len(failed) > 0 {
+		log.Printf("failed to render %d of %d files: %s", len(failed), len(r.args), strings.Join(failed, ", "))
+		return rc
+	} else if stale > 0 {
+		return exitStale
+	}
+
+	if r.check && r.pattern == nil {
+		if r.newline {
+			r.checked.WriteByte('\n')
+		}
+		return exitCode(checkOutput(r.outputPath, r.checked.Bytes()))
+	}
+	if r.pattern != nil || !r.dryRun {
+		return exitOK
+	}
+
+	if r.newline {
+		r.dryRunSize++
+	}
+	if r.outputPath == "" || r.outputPath == "-" {
+		log.Printf("would write %d bytes to stdout", r.dryRunSize)
+	} else {
+		log.Printf("would %s %s (%d bytes)", outputAction(r.outputPath), r.outputPath, r.dryRunSize)
+	}
+	return exitOK
+}
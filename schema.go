@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/nilium/resify/render"
+	"github.com/nilium/resify/rtype"
+)
+
+// schemaField describes a field or method available to templates, as found by reflecting over rtype.Resume.
+type schemaField struct {
+	Name   string        `json:"name"`             // The field's name in templates, such as "Chosen" for .Me.Chosen.
+	Key    string        `json:"key,omitempty"`    // The field's key in resume files, if it has one.
+	Inline bool          `json:"inline,omitempty"` // Whether the field's keys are inlined into its parent's.
+	Type   string        `json:"type"`             // The field's type, or a method's result type.
+	Args   []string      `json:"args,omitempty"`   // The types of a method's arguments.
+	Method bool          `json:"method,omitempty"` // Whether this is a method instead of a field.
+	Fields []schemaField `json:"fields,omitempty"` // The fields of a struct, or of the elements of a list or map of structs.
+}
+
+// timeType is treated as a single value instead of a struct with fields.
+var timeType = reflect.TypeOf(time.Time{})
+
+// resumeSchema returns the fields and methods available to templates: those of an rtype.Resume, followed by the other
+// fields of render.Data, such as .Extra, which aren't read from resume files.
+func resumeSchema() []schemaField {
+	fields := structSchema(reflect.TypeOf(rtype.Resume{}), true, nil)
+	data := reflect.TypeOf(render.Data{})
+	for i := 0; i < data.NumField(); i++ {
+		if f := data.Field(i); !f.Anonymous {
+			fields = append(fields, schemaField{Name: f.Name, Type: schemaTypeName(f.Type)})
+		}
+	}
+	return fields
+}
+
+// schemaTypeName returns the name of t as it's written in Go, without the rtype package qualifier.
+func schemaTypeName(t reflect.Type) string {
+	return strings.Replace(t.String(), "rtype.", "", -1)
+}
+
+// structSchema returns the exported fields of the struct type t, followed by its methods that templates can call. If keys is
+// true, t is read from resume files and its fields are given their keys. The types in seen are those being described by
+// its callers, which aren't described again to avoid recursing forever.
+func structSchema(t reflect.Type, keys bool, seen []reflect.Type) []schemaField {
+	seen = append(seen[:len(seen):len(seen)], t)
+
+	var fields []schemaField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		field := schemaField{Name: f.Name, Type: schemaTypeName(f.Type)}
+		if keys {
+			tag := strings.Split(f.Tag.Get("yaml"), ",")
+			field.Key = tag[0]
+			for _, opt := range tag[1:] {
+				field.Inline = field.Inline || opt == "inline"
+			}
+			if field.Key == "" && !field.Inline {
+				field.Key = strings.ToLower(f.Name)
+			}
+		}
+
+		if elem := structElem(f.Type); elem != nil && !containsType(seen, elem) {
+			field.Fields = structSchema(elem, keys, seen)
+		}
+		fields = append(fields, field)
+	}
+
+	for i := 0; i < t.NumMethod(); i++ {
+		m := t.Method(i)
+		if strings.HasPrefix(m.Name, "Marshal") || strings.HasPrefix(m.Name, "Unmarshal") {
+			continue
+		}
+
+		typ := m.Type
+		// Templates can only call methods with one result, or two if the second is an error.
+		switch {
+		case typ.NumOut() == 1:
+		case typ.NumOut() == 2 && typ.Out(1) == reflect.TypeOf((*error)(nil)).Elem():
+		default:
+			continue
+		}
+
+		method := schemaField{Name: m.Name, Type: schemaTypeName(typ.Out(0)), Method: true}
+		// The first argument is the receiver.
+		for j := 1; j < typ.NumIn(); j++ {
+			method.Args = append(method.Args, schemaTypeName(typ.In(j)))
+		}
+		if elem := structElem(typ.Out(0)); elem != nil && !containsType(seen, elem) {
+			method.Fields = structSchema(elem, false, seen)
+		}
+		fields = append(fields, method)
+	}
+
+	return fields
+}
+
+// structElem returns the struct type held by t, if t is a struct or a pointer, list, or map of structs, or nil otherwise.
+// Times aren't treated as structs.
+func structElem(t reflect.Type) reflect.Type {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType {
+		return nil
+	}
+	return t
+}
+
+func containsType(types []reflect.Type, t reflect.Type) bool {
+	for _, u := range types {
+		if u == t {
+			return true
+		}
+	}
+	return false
+}
+
+// writeSchema writes an outline of the fields and methods available to templates to w. If asJSON is true, the outline is
+// written as JSON instead, pretty-printed if indent is true.
+func writeSchema(w io.Writer, asJSON, indent bool) error {
+	fields := resumeSchema()
+	if asJSON {
+		marshal := json.Marshal
+		if indent {
+			marshal = func(v interface{}) ([]byte, error) { return json.MarshalIndent(v, "", "  ") }
+		}
+		b, err := marshal(fields)
+		if err != nil {
+			return err
+		}
+		return writeAll(w, b)
+	}
+
+	var b strings.Builder
+	writeSchemaFields(&b, fields, "")
+	return writeAll(w, []byte(strings.TrimSuffix(b.String(), "\n")))
+}
+
+// writeSchemaFields writes one line for each field, followed by its own fields indented beneath it, as in:
+//
+//  .Me (me): Me
+//    .Chosen (chosen): string
+//    .Name(): string
+func writeSchemaFields(b *strings.Builder, fields []schemaField, indent string) {
+	for _, f := range fields {
+		b.WriteString(indent + "." + f.Name)
+		switch {
+		case f.Method:
+			b.WriteString("(" + strings.Join(f.Args, ", ") + ")")
+		case f.Inline:
+			b.WriteString(" (inline)")
+		case f.Key != "":
+			b.WriteString(" (" + f.Key + ")")
+		}
+		fmt.Fprintf(b, ": %s\n", f.Type)
+		writeSchemaFields(b, f.Fields, indent+"  ")
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWriteSchema(t *testing.T) {
+	var buf strings.Builder
+	if err := writeSchema(&buf, false, false); err != nil {
+		t.Fatalf("unexpected error writing schema: %v", err)
+	}
+
+	for _, line := range []string{
+		".Me (me): Me",
+		"  .Chosen (chosen): string",
+		"  .Meta (inline): Meta",
+		"  .Name(): string",
+		".Employment (work): []Employment",
+		"  .When (when): DateRange",
+		"    .From (from): time.Time",
+		"    .Format(string): string",
+		"    .Duration(): Span",
+		"      .Years: int",
+		"    .Line(): string",
+		".References (references): []Reference",
+		".Extra: map[string]string",
+		".SourceModTime: time.Time",
+		".RenderedAt: time.Time",
+	} {
+		if !strings.Contains("\n"+buf.String()+"\n", "\n"+line+"\n") {
+			t.Errorf("expected schema to contain line %q; got:\n%s", line, buf.String())
+		}
+	}
+
+	if strings.Contains(buf.String(), "Marshal") || strings.Contains(buf.String(), ".Include.") {
+		t.Errorf("expected schema without marshalling methods; got:\n%s", buf.String())
+	}
+}
+
+func TestWriteSchemaJSON(t *testing.T) {
+	var buf strings.Builder
+	if err := writeSchema(&buf, true, true); err != nil {
+		t.Fatalf("unexpected error writing schema: %v", err)
+	}
+
+	var fields []schemaField
+	if err := json.Unmarshal([]byte(buf.String()), &fields); err != nil {
+		t.Fatalf("cannot decode schema JSON: %v", err)
+	}
+
+	keys := map[string]schemaField{}
+	for _, f := range fields {
+		keys[f.Key] = f
+	}
+	for _, key := range []string{"me", "profiles", "work", "education", "awards", "publications", "references", "include"} {
+		if _, ok := keys[key]; !ok {
+			t.Errorf("expected a field with key %q in schema", key)
+		}
+	}
+	if me := keys["me"]; len(me.Fields) == 0 || me.Fields[0].Name != "Order" || me.Fields[0].Key != "ordered" {
+		t.Errorf("expected Me's fields in schema; got %+v", me)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmlt "html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/nilium/resify/render"
+)
+
+// staticPrefix is the path under which the serve command serves files beneath dataDir.
+const staticPrefix = "/static/"
+
+// previewHandler renders a resume file for each request to /. The resume is re-read on every request, and templates are
+// loaded again whenever a file beneath dataDir (or the template file given by -template) has changed, so changes to either
+// show up on the next request. Unless noReload is set, in which case templates are loaded once and kept.
+//
+// A previewHandler is safe for concurrent use. Each request renders with the templates loaded when it began, even if they're
+// reloaded before it finishes.
+type previewHandler struct {
+	path     string // The resume file to render.
+	ext      string // The template extension.
+	template string // The template to execute.
+	useText  bool
+	opts     readOptions
+	noReload bool // Whether templates are kept once loaded instead of reloaded when they change.
+
+	mu       sync.Mutex
+	renderer *render.Renderer     // The templates last loaded, or nil if they haven't been.
+	loaded   rendererKey          // What renderer was loaded for.
+	snap     map[string]fileState // The state of the files renderer was loaded from when it was loaded.
+}
+
+// rendererKey is what a previewHandler's templates are loaded for. If it changes, they're loaded again.
+type rendererKey struct {
+	ext, template string
+	useText       bool
+}
+
+func newPreviewServer(h *previewHandler) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/", h)
+	mux.Handle(staticPrefix, http.StripPrefix(staticPrefix, http.FileServer(http.FS(staticFS{dataDirFS(dataDir)}))))
+	return mux
+}
+
+// staticFS is the filesystem served under staticPrefix. Like the dataDirFS it wraps, symlinks in it can't reach files
+// outside of its directory, but such files don't exist instead of being an error, so they're served as not found.
+type staticFS struct {
+	dataDirFS
+}
+
+func (s staticFS) Open(name string) (fs.File, error) {
+	f, err := s.dataDirFS.Open(name)
+	if errors.Is(err, render.ErrEscapeAttempt) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return f, err
+}
+
+func (h *previewHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != "/" {
+		http.NotFound(w, req)
+		return
+	}
+
+	b, contentType, err := h.render()
+	if err != nil {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "<!DOCTYPE html>\n<title>resify: render failed</title>\n<h1>Cannot render %s</h1>\n<pre>%s</pre>\n",
+			htmlt.HTMLEscapeString(h.path), htmlt.HTMLEscapeString(err.Error()))
+		return
+	}
+
+	switch {
+	case contentType != "":
+		w.Header().Set("Content-Type", contentType)
+	case markdownOutput:
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	case h.useText:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	default:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}
+	w.Write(b)
+}
+
+// templateFiles returns the files and directories that the handler's templates are loaded from.
+func (h *previewHandler) templateFiles() []string {
+	paths := []string{dataDir}
+	if h.template != "-" && isTemplatePath(h.template) {
+		paths = append(paths, h.template)
+	}
+	return paths
+}
+
+// loadRenderer returns the handler's templates, loading them if they haven't been or, unless noReload is set, if the files
+// they were loaded from have changed since. Templates that fail to load aren't kept, so they're loaded again by the next
+// request. Errors are logged before being returned.
+func (h *previewHandler) loadRenderer() (*render.Renderer, error) {
+	key := rendererKey{ext: h.ext, template: h.template, useText: h.useText}
+
+	// Files are checked before taking the lock, so that requests only wait on each other to load templates. The files are
+	// checked before loading, so changes made while loading are seen by the next request.
+	var snap map[string]fileState
+	if !h.noReload {
+		snap = snapshotFiles(h.templateFiles())
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.renderer != nil && h.loaded == key && (h.noReload || sameSnapshot(snap, h.snap)) {
+		return h.renderer, nil
+	}
+
+	if h.renderer != nil {
+		debugf("reloading templates")
+	}
+	r, err := newRenderer(h.useText, h.ext, h.template)
+	if err != nil {
+		return nil, err
+	}
+	h.renderer, h.loaded, h.snap = r, key, snap
+	return r, nil
+}
+
+// render renders the resume file with the handler's templates (see loadRenderer). It returns the result along with the
+// content type given by the main template's front matter, if any. Errors are logged before being returned.
+func (h *previewHandler) render() ([]byte, string, error) {
+	renderer, err := h.loadRenderer()
+	if err != nil {
+		return nil, "", err
+	}
+
+	resume, err := readResumeFromFile(h.path, h.opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var buf bytes.Buffer
+	if err = renderer.Execute(&buf, render.Data{Resume: resume, SourceModTime: sourceModTime(h.path)}); err != nil {
+		log.Println("cannot execute template:", err)
+		return nil, "", err
+	}
+	return trimOutput(buf.Bytes()), renderer.FrontMatter().ContentType, nil
+}
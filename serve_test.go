@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPreviewServer(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"templates/index.tem":  "<h1>{{ .Me.Chosen }}</h1>",
+		"templates/broken.tem": "{{ .Me.Missing }}",
+		"templates/xml.tem":    "---\ncontentType: application/xml\n---\n<me>{{ .Me.Chosen }}</me>",
+		"templates/style.css":  "h1 {}",
+		"me.yaml":              "me: {chosen: Jane}\n",
+		"secret.txt":           "secret",
+	})
+
+	defer func(d string) { dataDir = d }(dataDir)
+	dataDir = filepath.Join(dir, "templates")
+
+	// Static files can't be served from outside of the templates directory through symlinks.
+	for name, target := range map[string]string{"escape.txt": filepath.Join(dir, "secret.txt"), "parent": dir} {
+		if err := os.Symlink(target, filepath.Join(dataDir, name)); err != nil {
+			t.Skipf("cannot create symlinks: %v", err)
+		}
+	}
+
+	handler := &previewHandler{path: filepath.Join(dir, "me.yaml"), ext: ".tem"}
+	srv := newPreviewServer(handler)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec
+	}
+
+	if rec := get("/"); rec.Code != http.StatusOK || rec.Body.String() != "<h1>Jane</h1>" {
+		t.Errorf("GET / = %d %q; want 200 %q", rec.Code, rec.Body, "<h1>Jane</h1>")
+	}
+
+	if rec := get("/static/style.css"); rec.Code != http.StatusOK || rec.Body.String() != "h1 {}" {
+		t.Errorf("GET /static/style.css = %d %q; want 200 %q", rec.Code, rec.Body, "h1 {}")
+	}
+
+	for _, path := range []string{"/static/escape.txt", "/static/parent/secret.txt"} {
+		if rec := get(path); rec.Code != http.StatusNotFound || strings.Contains(rec.Body.String(), "secret") {
+			t.Errorf("GET %s = %d %q; want 404", path, rec.Code, rec.Body)
+		}
+	}
+
+	if rec := get("/other"); rec.Code != http.StatusNotFound {
+		t.Errorf("GET /other = %d; want 404", rec.Code)
+	}
+
+	handler.template = "xml"
+	if rec := get("/"); rec.Header().Get("Content-Type") != "application/xml" || rec.Body.String() != "<me>Jane</me>" {
+		t.Errorf("GET / with front matter = %q %q; want %q %q", rec.Header().Get("Content-Type"), rec.Body, "application/xml", "<me>Jane</me>")
+	}
+
+	handler.template = "broken"
+	if rec := get("/"); rec.Code != http.StatusInternalServerError || !strings.Contains(rec.Body.String(), "Missing") {
+		t.Errorf("GET / with broken template = %d %q; want 500 with template error", rec.Code, rec.Body)
+	}
+}
+
+func TestPreviewServerReload(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"templates/index.tem": "<h1>{{ .Me.Chosen }}</h1>",
+		"me.yaml":             "me: {chosen: Jane}\n",
+	})
+
+	defer func(d string) { dataDir = d }(dataDir)
+	dataDir = filepath.Join(dir, "templates")
+
+	get := func(h http.Handler) string {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		return rec.Body.String()
+	}
+
+	reloading := &previewHandler{path: filepath.Join(dir, "me.yaml"), ext: ".tem"}
+	pinned := &previewHandler{path: filepath.Join(dir, "me.yaml"), ext: ".tem", noReload: true}
+	for _, h := range []*previewHandler{reloading, pinned} {
+		if got := get(newPreviewServer(h)); got != "<h1>Jane</h1>" {
+			t.Fatalf("GET / (noReload=%t) = %q; want %q", h.noReload, got, "<h1>Jane</h1>")
+		}
+	}
+	loaded := reloading.renderer
+	if get(newPreviewServer(reloading)); reloading.renderer != loaded {
+		t.Error("expected unchanged templates not to be reloaded")
+	}
+
+	writeFiles(t, dir, map[string]string{"templates/index.tem": "<h2>{{ .Me.Chosen }}</h2>\n"})
+	if got := get(newPreviewServer(reloading)); got != "<h2>Jane</h2>" {
+		t.Errorf("GET / after changing templates = %q; want %q", got, "<h2>Jane</h2>")
+	}
+	if got := get(newPreviewServer(pinned)); got != "<h1>Jane</h1>" {
+		t.Errorf("GET / with noReload after changing templates = %q; want %q", got, "<h1>Jane</h1>")
+	}
+}
+
+// TestPreviewServerConcurrentReload serves requests concurrently while templates change, and is meant to be run with the
+// race detector. Every response must be rendered entirely by one version of the templates.
+func TestPreviewServerConcurrentReload(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"templates/index.tem": `{{ template "a.tem" . }}{{ template "b.tem" . }}`,
+		"templates/a.tem":     "a0",
+		"templates/b.tem":     "b0",
+		"me.yaml":             "me: {chosen: Jane}\n",
+	})
+
+	defer func(d string) { dataDir = d }(dataDir)
+	dataDir = filepath.Join(dir, "templates")
+	srv := newPreviewServer(&previewHandler{path: filepath.Join(dir, "me.yaml"), ext: ".tem"})
+
+	const versions = 20
+	done := make(chan struct{})
+	errs := make(chan string, 8)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				rec := httptest.NewRecorder()
+				srv.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+				body := rec.Body.String()
+				// A version may be read while only one of its files has been written, which is a consistent set of files as
+				// far as the server can tell, so only check that the response is complete.
+				if rec.Code != http.StatusOK || !strings.HasPrefix(body, "a") || !strings.Contains(body, "b") {
+					errs <- fmt.Sprintf("GET / = %d %q", rec.Code, body)
+					return
+				}
+			}
+		}()
+	}
+
+	for v := 1; v <= versions; v++ {
+		// Files are replaced by renaming, as editors do, so that they're never seen half-written.
+		for _, name := range []string{"a", "b"} {
+			writeFiles(t, dir, map[string]string{name + ".tmp": fmt.Sprintf("%s%d", name, v)})
+			if err := os.Rename(filepath.Join(dir, name+".tmp"), filepath.Join(dataDir, name+".tem")); err != nil {
+				t.Fatal(err)
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(done)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if want := fmt.Sprintf("a%db%d", versions, versions); rec.Body.String() != want {
+		t.Errorf("GET / after the last change = %q; want %q", rec.Body, want)
+	}
+}
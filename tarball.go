@@ -0,0 +1,124 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+var errTarballEscape = errors.New("attempt to leave tarball via ..")
+
+// isTarball returns whether the -data-dir path is a gzipped tarball of templates instead of a directory.
+func isTarball(path string) bool {
+	return strings.HasSuffix(path, ".tar.gz") || strings.HasSuffix(path, ".tgz")
+}
+
+// extractTarball extracts the gzipped tarball at path into a new temporary directory and returns that directory, which
+// the caller must remove. Only directories and regular files are extracted. Entries with absolute paths, paths that
+// leave the tarball through "..", and links are rejected, and nothing is left behind if any are found.
+func extractTarball(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return "", err
+	}
+	defer gz.Close()
+
+	dir, err := ioutil.TempDir("", "resify-templates-")
+	if err != nil {
+		return "", err
+	}
+	if err = extractTar(dir, tar.NewReader(gz)); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+// extractTar extracts the entries read from tr into dir, as described by extractTarball.
+func extractTar(dir string, tr *tar.Reader) error {
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		name, err := tarballPath(hdr.Name)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0755)
+		case tar.TypeReg:
+			err = extractTarballFile(target, tr)
+		case tar.TypeSymlink, tar.TypeLink:
+			err = fmt.Errorf("%s: links are not allowed in tarballs", hdr.Name)
+		default:
+			debugf("skipping tarball entry %s of type %q", hdr.Name, hdr.Typeflag)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// tarballPath returns the cleaned, slash-separated path of a tarball entry, or an error if the entry's name is absolute
+// or leaves the tarball.
+func tarballPath(name string) (string, error) {
+	if path.IsAbs(name) || filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("%s: absolute paths are not allowed in tarballs", name)
+	}
+	clean := path.Clean(filepath.ToSlash(name))
+	if escapesDir(clean) {
+		return "", fmt.Errorf("%s: %w", name, errTarballEscape)
+	}
+	return clean, nil
+}
+
+// extractTarballFile writes the contents of r to a new file at path, creating its directory if needed.
+func extractTarballFile(path string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// removeOnSignal removes dir and exits if resify is interrupted or terminated, such as while watching or serving, since
+// deferred calls aren't run then.
+func removeOnSignal(dir string) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		os.RemoveAll(dir)
+		os.Exit(exitFailure)
+	}()
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTarball writes a gzipped tarball of the given headers to a file in a new temporary directory and returns its
+// path. Regular files hold their own names as content.
+func writeTarball(t *testing.T, headers ...tar.Header) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "templates.tar.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, hdr := range headers {
+		hdr := hdr
+		hdr.Mode = 0644
+		if hdr.Typeflag == tar.TypeReg {
+			hdr.Size = int64(len(hdr.Name))
+		}
+		if err := tw.WriteHeader(&hdr); err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(hdr.Name)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExtractTarball(t *testing.T) {
+	path := writeTarball(t,
+		tar.Header{Name: "./", Typeflag: tar.TypeDir},
+		tar.Header{Name: "./index.tem", Typeflag: tar.TypeReg},
+		tar.Header{Name: "partials/header.tem", Typeflag: tar.TypeReg},
+	)
+
+	dir, err := extractTarball(path)
+	if err != nil {
+		t.Fatalf("unexpected error extracting tarball: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, want := range map[string]string{
+		"index.tem":           "./index.tem",
+		"partials/header.tem": "partials/header.tem",
+	} {
+		b, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Errorf("cannot read extracted %s: %v", name, err)
+		} else if string(b) != want {
+			t.Errorf("extracted %s = %q; want %q", name, b, want)
+		}
+	}
+
+	// The extracted tree is read the same as any templates directory.
+	if b, err := fs.ReadFile(dataDirFS(dir), "partials/header.tem"); err != nil || string(b) != "partials/header.tem" {
+		t.Errorf("cannot read extracted file through data directory: %q, %v", b, err)
+	}
+}
+
+func TestExtractTarballRejected(t *testing.T) {
+	tests := []struct {
+		name string
+		hdr  tar.Header
+	}{
+		{"parent", tar.Header{Name: "../escape.tem", Typeflag: tar.TypeReg}},
+		{"nested parent", tar.Header{Name: "partials/../../escape.tem", Typeflag: tar.TypeReg}},
+		{"absolute", tar.Header{Name: "/tmp/escape.tem", Typeflag: tar.TypeReg}},
+		{"symlink", tar.Header{Name: "link.tem", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"}},
+		{"hard link", tar.Header{Name: "link.tem", Typeflag: tar.TypeLink, Linkname: "index.tem"}},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			path := writeTarball(t, tar.Header{Name: "index.tem", Typeflag: tar.TypeReg}, c.hdr)
+			if dir, err := extractTarball(path); err == nil {
+				os.RemoveAll(dir)
+				t.Fatalf("expected error extracting tarball with entry %q", c.hdr.Name)
+			}
+		})
+	}
+
+	_, err := tarballPath("a/../../b")
+	if !errors.Is(err, errTarballEscape) {
+		t.Errorf("expected errTarballEscape; got %v", err)
+	}
+}
+
+func TestIsTarball(t *testing.T) {
+	for path, want := range map[string]bool{
+		"templates":         false,
+		"templates.tar.gz":  true,
+		"ci/templates.tgz":  true,
+		"templates.tar":     false,
+		"templates.tar.gz/": false,
+	} {
+		if got := isTarball(path); got != want {
+			t.Errorf("isTarball(%q) = %t; want %t", path, got, want)
+		}
+	}
+}
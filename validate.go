@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/nilium/resify/rtype"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// problem is a single structural problem found in a resume. Path is the YAML path to the offending field.
+type problem struct {
+	Path string
+	Msg  string
+}
+
+func (p problem) String() string {
+	return p.Path + ": " + p.Msg
+}
+
+// validateResume checks a parsed resume for problems: empty required fields, dates that could not be parsed (see
+// readOptions.KeepBadDates), date ranges that end before they start, and profile URLs that cannot be parsed or have no
+// scheme. Problems are returned in the order they appear in the resume.
+func validateResume(r rtype.Resume) (problems []problem) {
+	report := func(path, format string, args ...interface{}) {
+		problems = append(problems, problem{Path: path, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	required := func(path, value string) {
+		if len(strings.Trim(value, whitespace)) == 0 {
+			report(path, "required field is empty")
+		}
+	}
+
+	dates := func(path string, d rtype.DateRange) {
+		if err := d.Err(); err != nil {
+			report(path, "%v", err)
+		} else if !d.From.IsZero() && !d.To.IsZero() && d.To.Before(d.From) {
+			report(path, "range ends (%s) before it starts (%s)", d.To.Format("2006-01-02"), d.From.Format("2006-01-02"))
+		}
+	}
+
+	required("me.chosen", r.Me.Chosen)
+
+	keys := make([]string, 0, len(r.Profiles.Profile))
+	for k := range r.Profiles.Profile {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		raw := r.Profiles.Profile[k].URL
+		if u, err := url.Parse(raw); err != nil {
+			report("profiles."+k+".url", "cannot parse URL: %v", err)
+		} else if raw != "" && !u.IsAbs() {
+			report("profiles."+k+".url", "URL %q has no scheme, such as https://", raw)
+		}
+	}
+
+	for i, e := range r.Employment {
+		path := fmt.Sprintf("work[%d]", i)
+		required(path+".title", e.Title)
+		dates(path+".when", e.When)
+	}
+
+	for i, e := range r.Education {
+		path := fmt.Sprintf("education[%d]", i)
+		required(path+".where.name", e.Where.Name)
+		dates(path+".when", e.When)
+	}
+
+	for i, e := range r.Awards {
+		path := fmt.Sprintf("awards[%d]", i)
+		required(path+".title", e.Title)
+		dates(path+".date", e.Date)
+	}
+
+	for i, e := range r.Publications {
+		path := fmt.Sprintf("publications[%d]", i)
+		required(path+".title", e.Title)
+		dates(path+".date", e.Date)
+		if _, err := url.Parse(e.URL); err != nil {
+			report(path+".url", "cannot parse URL: %v", err)
+		}
+	}
+
+	for i, e := range r.References {
+		required(fmt.Sprintf("references[%d].name", i), e.Name)
+	}
+
+	return problems
+}
+
+// dateErrors returns the errors parsing the dates of r, if any of them could not be parsed (see rtype.DateRange.Err).
+func dateErrors(r rtype.Resume) (errs []error) {
+	var dates []rtype.DateRange
+	for _, e := range r.Employment {
+		dates = append(dates, e.When)
+	}
+	for _, e := range r.Education {
+		dates = append(dates, e.When)
+	}
+	for _, e := range r.Awards {
+		dates = append(dates, e.Date)
+	}
+	for _, e := range r.Publications {
+		dates = append(dates, e.Date)
+	}
+
+	for _, d := range dates {
+		if err := d.Err(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// withoutDateErrors returns err without the errors parsing the dates of r, which was decoded despite them (see
+// rtype.DateRange.UnmarshalYAML). It returns nil if err held nothing else, or err as it is if r wasn't decoded.
+func withoutDateErrors(err error, r rtype.Resume) error {
+	typeErr, ok := err.(*yaml.TypeError)
+	if !ok {
+		return err
+	}
+
+	bad := map[string]int{}
+	for _, err := range dateErrors(r) {
+		bad[err.Error()]++
+	}
+
+	var rest []string
+	for _, msg := range typeErr.Errors {
+		if bad[msg] > 0 {
+			bad[msg]--
+		} else {
+			rest = append(rest, msg)
+		}
+	}
+	if len(rest) == 0 {
+		return nil
+	}
+	return &yaml.TypeError{Errors: rest}
+}
+
+// validateFiles reads and validates each of the resume files given, logging one line per problem found. It returns false if
+// any file could not be read or had problems.
+func validateFiles(paths []string, opts readOptions) (ok bool) {
+	ok = true
+	for _, path := range paths {
+		name := path
+		if path == "-" || path == "" {
+			name = "stdin"
+		}
+
+		resume, err := readResumeFromFile(path, opts)
+		if err != nil {
+			ok = false
+			continue
+		}
+
+		for _, p := range validateResume(resume) {
+			log.Printf("%s: %v", name, p)
+			ok = false
+		}
+	}
+	return ok
+}
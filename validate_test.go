@@ -0,0 +1,102 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nilium/resify/rtype"
+)
+
+func TestValidateResume(t *testing.T) {
+	mustRange := func(from, to string) rtype.DateRange {
+		d, err := rtype.NewDateRange(from, to)
+		if err != nil {
+			t.Fatalf("cannot parse date range %q-%q: %v", from, to, err)
+		}
+		return d
+	}
+
+	r := rtype.Resume{
+		Profiles: rtype.Profiles{
+			Profile: map[string]rtype.Profile{
+				"ok":  {URL: "https://example.com/me"},
+				"bad": {URL: "http://[::1"},
+				"rel": {URL: "me/profile"},
+			},
+		},
+		Employment: []rtype.Employment{
+			{Title: "Fine", When: mustRange("2010-01", "2012-01")},
+			{Title: " ", When: mustRange("2012-01", "2010-01")},
+		},
+		Education: []rtype.Education{
+			{When: mustRange("2010", "")},
+		},
+		Awards: []rtype.Award{
+			{Title: "Fine", Date: mustRange("2014-05", "")},
+			{Date: mustRange("2015", "2014")},
+		},
+		Publications: []rtype.Publication{
+			{Title: "Fine", Date: mustRange("2016-03", ""), URL: "https://example.com/paper"},
+			{Title: "Bad URL", Date: mustRange("2016", ""), URL: "http://[::1"},
+		},
+		References: []rtype.Reference{
+			{Name: "Fine"},
+			{Relationship: "Nameless"},
+		},
+	}
+
+	var paths []string
+	for _, p := range validateResume(r) {
+		paths = append(paths, p.Path)
+	}
+
+	want := []string{
+		"me.chosen",
+		"profiles.bad.url",
+		"profiles.rel.url",
+		"work[1].title",
+		"work[1].when",
+		"education[0].where.name",
+		"awards[1].title",
+		"awards[1].date",
+		"publications[1].url",
+		"references[1].name",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("expected problems at %q; got %q", want, paths)
+	}
+}
+
+func TestValidateBadDates(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"bad.yaml": "work:\n" +
+			"  - title: First\n    when: sometime\n" +
+			"  - title: Second\n    when: {from: 2015-08, to: 2016-13}\n" +
+			"awards:\n  - title: Prize\n    date: 2014\n",
+	})
+
+	logged, rc := runMain(t, dir, "validate", "bad.yaml")
+	if rc != exitParse {
+		t.Errorf("exit code = %d; want %d\n%s", rc, exitParse, logged)
+	}
+	for _, want := range []string{
+		`bad.yaml: me.chosen: `,
+		`bad.yaml: work[0].when: from: cannot parse date "sometime"`,
+		`bad.yaml: work[1].when: to: cannot parse date "2016-13"`,
+	} {
+		if !strings.Contains(logged, want) {
+			t.Errorf("expected %q in output:\n%s", want, logged)
+		}
+	}
+	if strings.Contains(logged, "cannot parse bad.yaml") {
+		t.Errorf("expected bad dates not to fail the read:\n%s", logged)
+	}
+
+	// Rendering still fails on the first bad date.
+	writeFiles(t, dir, map[string]string{"templates/index.tem": "{{ .Me.Chosen }}"})
+	if logged, rc := runMain(t, dir, "render", "bad.yaml"); rc != exitParse {
+		t.Errorf("render exit code = %d; want %d\n%s", rc, exitParse, logged)
+	}
+}
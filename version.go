@@ -0,0 +1,51 @@
+package main
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// version is the version of resify, set at build time with:
+//
+//  go build -ldflags "-X main.version=v1.2.3"
+var version string
+
+// versionString returns the version of resify, the version of Go it was built with, and, if known, the VCS revision it was
+// built from. If no version was set at build time, the version is "dev".
+func versionString() string {
+	v := version
+	if v == "" {
+		v = "dev"
+	}
+
+	s := "resify " + v + " " + runtime.Version()
+	if rev := vcsRevision(); rev != "" {
+		s += " " + rev
+	}
+	return s
+}
+
+// vcsRevision returns the VCS revision recorded in the binary's build info, with "-dirty" appended if the working tree was
+// modified. If there's no revision, it returns an empty string.
+func vcsRevision() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+
+	var rev, dirty string
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			rev = s.Value
+		case "vcs.modified":
+			if s.Value == "true" {
+				dirty = "-dirty"
+			}
+		}
+	}
+	if rev == "" {
+		return ""
+	}
+	return rev + dirty
+}
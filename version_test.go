@@ -0,0 +1,21 @@
+package main
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestVersionString(t *testing.T) {
+	defer func(v string) { version = v }(version)
+
+	version = ""
+	if got, want := versionString(), "resify dev "+runtime.Version(); !strings.HasPrefix(got, want) {
+		t.Errorf("versionString() = %q; want prefix %q", got, want)
+	}
+
+	version = "v1.2.3"
+	if got, want := versionString(), "resify v1.2.3 "+runtime.Version(); !strings.HasPrefix(got, want) {
+		t.Errorf("versionString() = %q; want prefix %q", got, want)
+	}
+}
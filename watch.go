@@ -0,0 +1,75 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	watchInterval = 250 * time.Millisecond // How often watched files are checked for changes.
+	watchSettle   = 500 * time.Millisecond // How long watched files must go unchanged before calling back.
+)
+
+// fileState is the state of a watched file used to detect changes.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// snapshotFiles returns the state of each of paths, including every file beneath any directories. Paths that cannot be read
+// are left out, so their removal or creation is also a change.
+func snapshotFiles(paths []string) map[string]fileState {
+	snap := map[string]fileState{}
+	for _, root := range paths {
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			if fi, err := os.Stat(path); err == nil {
+				snap[path] = fileState{modTime: fi.ModTime(), size: fi.Size()}
+			}
+			return nil
+		})
+	}
+	return snap
+}
+
+func sameSnapshot(a, b map[string]fileState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, state := range a {
+		if other, ok := b[path]; !ok || other != state {
+			return false
+		}
+	}
+	return true
+}
+
+// watchFiles polls the files returned by paths (and everything beneath any directories) for changes every interval. Once a
+// change has been seen and the files have gone unchanged for settle, changed is called. This debounces editors that write
+// several times per save. Paths is called before each poll, so the files watched may change, such as after calling changed.
+// The files watched are logged once they're first read, so changes made after that are never missed. watchFiles never
+// returns.
+func watchFiles(paths func() []string, interval, settle time.Duration, changed func()) {
+	watched := paths()
+	last := snapshotFiles(watched)
+	infof("watching %s for changes", strings.Join(watched, ", "))
+	var changedAt time.Time
+	for {
+		time.Sleep(interval)
+
+		if snap := snapshotFiles(paths()); !sameSnapshot(snap, last) {
+			last, changedAt = snap, time.Now()
+			continue
+		}
+
+		if !changedAt.IsZero() && time.Since(changedAt) >= settle {
+			changedAt = time.Time{}
+			changed()
+		}
+	}
+}
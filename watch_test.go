@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnapshotFiles(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.tem")
+	b := filepath.Join(dir, "sub", "b.tem")
+	if err := os.MkdirAll(filepath.Dir(b), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{a, b} {
+		if err := os.WriteFile(p, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	before := snapshotFiles([]string{dir, filepath.Join(dir, "missing.yaml")})
+	if len(before) != 2 {
+		t.Fatalf("snapshotFiles() = %d files; want 2", len(before))
+	}
+	if !sameSnapshot(before, snapshotFiles([]string{dir})) {
+		t.Error("sameSnapshot() = false for unchanged files; want true")
+	}
+
+	if err := os.WriteFile(b, []byte("xyz"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if sameSnapshot(before, snapshotFiles([]string{dir})) {
+		t.Error("sameSnapshot() = true after file was modified; want false")
+	}
+
+	if err := os.Remove(a); err != nil {
+		t.Fatal(err)
+	}
+	if sameSnapshot(before, snapshotFiles([]string{dir})) {
+		t.Error("sameSnapshot() = true after file was removed; want false")
+	}
+}
+
+func TestWatchIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"templates/index.tem": "{{ range .Employment }}{{ .Title }} {{ end }}",
+		"resume.yaml":         "include: [work.yaml]\n",
+		"work.yaml":           "work:\n- title: First\n",
+	})
+
+	cmd := mainCommand(t, dir, "render", "-watch", "-o", "out.txt", "resume.yaml")
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Wait()
+	defer cmd.Process.Kill()
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		for s := bufio.NewScanner(stderr); s.Scan(); {
+			lines <- s.Text()
+		}
+	}()
+
+	// waitFor reads logged lines, which must all be timestamped, until one contains msg.
+	timestamped := regexp.MustCompile(`^\d\d:\d\d:\d\d `)
+	waitFor := func(msg string) {
+		t.Helper()
+		timeout := time.After(10 * time.Second)
+		for {
+			select {
+			case line, ok := <-lines:
+				if !ok {
+					t.Fatalf("resify exited before logging %q", msg)
+				}
+				if !timestamped.MatchString(line) {
+					t.Errorf("expected a timestamp on %q", line)
+				}
+				if strings.Contains(line, msg) {
+					return
+				}
+			case <-timeout:
+				t.Fatalf("timed out waiting for %q", msg)
+			}
+		}
+	}
+
+	waitFor("rendered 1 file(s)")
+	waitFor("work.yaml")
+	if b, err := os.ReadFile(filepath.Join(dir, "out.txt")); err != nil || string(b) != "First\n" {
+		t.Fatalf("out.txt = %q, %v; want %q", b, err, "First\n")
+	}
+
+	// Changing only the included file renders the resume again.
+	if err := os.WriteFile(filepath.Join(dir, "work.yaml"), []byte("work:\n- title: Second\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor("rendered 1 file(s)")
+	if b, err := os.ReadFile(filepath.Join(dir, "out.txt")); err != nil || string(b) != "Second\n" {
+		t.Errorf("out.txt = %q, %v; want %q", b, err, "Second\n")
+	}
+}